@@ -4,7 +4,9 @@ package goTrackRTP
 
 import (
 	"errors"
-	"log"
+	"fmt"
+	"math"
+	"time"
 )
 
 const (
@@ -15,63 +17,129 @@ const (
 )
 
 var (
-	ErrWindowAWMin     = errors.New("ErrWindow window ahead min")
-	ErrWindowAWMax     = errors.New("ErrWindow window ahead max")
-	ErrWindowBWMin     = errors.New("ErrWindow window behind min")
-	ErrWindowBWMax     = errors.New("ErrWindow window behind max")
-	ErrWindowABMin     = errors.New("ErrWindow buffer ahead min")
-	ErrWindowABMax     = errors.New("ErrWindow buffer ahead max")
-	ErrWindowBBMin     = errors.New("ErrWindow buffer behind min")
-	ErrWindowBBMax     = errors.New("ErrWindow buffer behind max")
-	ErrWindowDegreeMin = errors.New("ErrWindow degree min")
-	ErrWindowDegreeMax = errors.New("ErrWindow degree max")
+	ErrWindowAWMin      = errors.New("ErrWindow window ahead min")
+	ErrWindowAWMax      = errors.New("ErrWindow window ahead max")
+	ErrWindowBWMin      = errors.New("ErrWindow window behind min")
+	ErrWindowBWMax      = errors.New("ErrWindow window behind max")
+	ErrWindowABMin      = errors.New("ErrWindow buffer ahead min")
+	ErrWindowABMax      = errors.New("ErrWindow buffer ahead max")
+	ErrWindowBBMin      = errors.New("ErrWindow buffer behind min")
+	ErrWindowBBMax      = errors.New("ErrWindow buffer behind max")
+	ErrWindowDegreeMin  = errors.New("ErrWindow degree min")
+	ErrWindowDegreeMax  = errors.New("ErrWindow degree max")
+	ErrLateThresholdMax = errors.New("ErrLateThreshold max")
+	ErrStorage          = errors.New("ErrStorage unknown storage")
+	ErrTimeWindowAW     = errors.New("ErrTimeWindow window ahead must be positive")
+	ErrTimeWindowBW     = errors.New("ErrTimeWindow window behind must be positive")
+	ErrTimeWindowAB     = errors.New("ErrTimeWindow buffer ahead must be positive")
+	ErrTimeWindowBB     = errors.New("ErrTimeWindow buffer behind must be positive")
+	ErrTimeWindowRate   = errors.New("ErrTimeWindow rate must not be negative")
 )
 
+// ValidationError names the field which is invalid, the value, and its limits
+// Use errors.Is to match the underlying Err, e.g. ErrWindowAWMin
+type ValidationError struct {
+	Field string
+	Value any
+	Min   any
+	Max   any // nil if there is no maximum
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Max == nil {
+		return fmt.Sprintf("%s:%v, min:%v, %v", e.Field, e.Value, e.Min, e.Err)
+	}
+	return fmt.Sprintf("%s:%v, min:%v, max:%v, %v", e.Field, e.Value, e.Min, e.Max, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
 // validateNew performs simple min/max checks of the Tracker creation variables
+// All the violations are returned, using errors.Join
 func validateNew(aw uint16, bw uint16, ab uint16, bb uint16, degree int) error {
 
-	if aw <= MinWindowCst {
-		log.Printf("aw:%v, aw < MinWindowCst:%v", aw, MinWindowCst)
-		return ErrWindowAWMin
-	}
-	if aw > MaxWindowCst {
-		log.Printf("aw:%v, aw > MaxWindowCst:%v", aw, MaxWindowCst)
-		return ErrWindowAWMax
-	}
-	if bw <= MinWindowCst {
-		log.Printf("bw:%v, bw < MinWindowCst:%v", bw, MinWindowCst)
-		return ErrWindowBWMin
+	return errors.Join(
+		validateWindow("AW", aw, ErrWindowAWMin, ErrWindowAWMax),
+		validateWindow("BW", bw, ErrWindowBWMin, ErrWindowBWMax),
+		validateWindow("AB", ab, ErrWindowABMin, ErrWindowABMax),
+		validateWindow("BB", bb, ErrWindowBBMin, ErrWindowBBMax),
+		validateDegree(degree),
+	)
+}
+
+// validateWindow checks a window, or buffer, is above MinWindowCst, and within MaxWindowCst
+func validateWindow(field string, w uint16, errMin error, errMax error) error {
+
+	if w <= MinWindowCst {
+		return &ValidationError{Field: field, Value: w, Min: uint16(MinWindowCst + 1), Max: uint16(MaxWindowCst), Err: errMin}
 	}
-	if bw > MaxWindowCst {
-		log.Printf("bw:%v, bw > MaxWindowCst:%v", bw, MaxWindowCst)
-		return ErrWindowBWMax
+	if w > MaxWindowCst {
+		return &ValidationError{Field: field, Value: w, Min: uint16(MinWindowCst + 1), Max: uint16(MaxWindowCst), Err: errMax}
 	}
 
-	if ab <= MinWindowCst {
-		log.Printf("ab:%v, ab < MinWindowCst:%v", ab, MinWindowCst)
-		return ErrWindowABMin
+	return nil
+}
+
+// validateDegree checks the B-tree degree
+func validateDegree(degree int) error {
+
+	if degree < MinDegree {
+		return &ValidationError{Field: "Degree", Value: degree, Min: MinDegree, Max: MaxDegree, Err: ErrWindowDegreeMin}
 	}
-	if ab > MaxWindowCst {
-		log.Printf("ab:%v, ab > MaxWindowCst:%v", ab, MaxWindowCst)
-		return ErrWindowABMax
+	if degree > MaxDegree {
+		return &ValidationError{Field: "Degree", Value: degree, Min: MinDegree, Max: MaxDegree, Err: ErrWindowDegreeMax}
 	}
-	if bb <= MinWindowCst {
-		log.Printf("bb:%v, bb < MinWindowCst:%v", bb, MinWindowCst)
-		return ErrWindowBBMin
+
+	return nil
+}
+
+// validateLateThreshold checks the late threshold fits within the behind window
+func validateLateThreshold(lt uint16, bw uint16) error {
+
+	if lt > bw {
+		return &ValidationError{Field: "LateThreshold", Value: lt, Min: uint16(0), Max: bw, Err: ErrLateThresholdMax}
 	}
-	if bb > MaxWindowCst {
-		log.Printf("bb:%v, bb > MaxWindowCst:%v", bb, MaxWindowCst)
-		return ErrWindowBBMax
+
+	return nil
+}
+
+// validateStorage checks the storage is known
+func validateStorage(storage int) error {
+
+	if storage != StorageBTree && storage != StorageBitmap {
+		return &ValidationError{Field: "Storage", Value: storage, Min: StorageBTree, Max: StorageBitmap, Err: ErrStorage}
 	}
 
-	if degree < MinDegree {
-		log.Printf("degree:%v, degree < MinDegree:%v", degree, MinDegree)
-		return ErrWindowDegreeMin
+	return nil
+}
+
+// validateTimeWindows checks the time windows are positive
+// All the violations are returned, using errors.Join
+func validateTimeWindows(tw TimeWindows) error {
+
+	var errs []error
+
+	for _, w := range []struct {
+		field string
+		d     time.Duration
+		err   error
+	}{
+		{"Time.AW", tw.AW, ErrTimeWindowAW},
+		{"Time.BW", tw.BW, ErrTimeWindowBW},
+		{"Time.AB", tw.AB, ErrTimeWindowAB},
+		{"Time.BB", tw.BB, ErrTimeWindowBB},
+	} {
+		if w.d <= 0 {
+			errs = append(errs, &ValidationError{Field: w.field, Value: w.d, Min: time.Nanosecond, Err: w.err})
+		}
 	}
-	if degree > MaxDegree {
-		log.Printf("degree:%v, degree > MaxDegree:%v", degree, MaxDegree)
-		return ErrWindowDegreeMax
+
+	if tw.Rate < 0 || math.IsNaN(tw.Rate) || math.IsInf(tw.Rate, 0) {
+		errs = append(errs, &ValidationError{Field: "Time.Rate", Value: tw.Rate, Min: 0.0, Err: ErrTimeWindowRate})
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
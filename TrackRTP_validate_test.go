@@ -0,0 +1,104 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// validationErrors unwraps the errors.Join result into the ValidationErrors
+func validationErrors(err error) (ves []ValidationError) {
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return nil
+	}
+
+	for _, e := range joined.Unwrap() {
+		var ve *ValidationError
+		if errors.As(e, &ve) {
+			ves = append(ves, *ve)
+		}
+	}
+
+	return ves
+}
+
+func TestValidateNew(t *testing.T) {
+
+	type test struct {
+		name   string
+		aw     uint16
+		bw     uint16
+		ab     uint16
+		bb     uint16
+		degree int
+		want   []ValidationError
+	}
+
+	tests := []test{
+		{"ok", 10, 10, 10, 10, BtreeDegreeCst, nil},
+		{"aw", 3, 10, 10, 10, BtreeDegreeCst, []ValidationError{
+			{Field: "AW", Value: uint16(3), Min: uint16(4), Max: uint16(MaxWindowCst), Err: ErrWindowAWMin},
+		}},
+		{"all", 0, 1501, 1, 2000, MaxDegree + 1, []ValidationError{
+			{Field: "AW", Value: uint16(0), Min: uint16(4), Max: uint16(MaxWindowCst), Err: ErrWindowAWMin},
+			{Field: "BW", Value: uint16(1501), Min: uint16(4), Max: uint16(MaxWindowCst), Err: ErrWindowBWMax},
+			{Field: "AB", Value: uint16(1), Min: uint16(4), Max: uint16(MaxWindowCst), Err: ErrWindowABMin},
+			{Field: "BB", Value: uint16(2000), Min: uint16(4), Max: uint16(MaxWindowCst), Err: ErrWindowBBMax},
+			{Field: "Degree", Value: MaxDegree + 1, Min: MinDegree, Max: MaxDegree, Err: ErrWindowDegreeMax},
+		}},
+	}
+
+	// validation must not log
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	for i, tc := range tests {
+
+		err := validateNew(tc.aw, tc.bw, tc.ab, tc.bb, tc.degree)
+		if tc.want == nil {
+			if err != nil {
+				t.Fatalf("%s, test:%d %s err:%v", t.Name(), i, tc.name, err)
+			}
+			continue
+		}
+
+		if ves := validationErrors(err); !reflect.DeepEqual(ves, tc.want) {
+			t.Fatalf("%s, test:%d %s errors:%+v != tc.want:%+v", t.Name(), i, tc.name, ves, tc.want)
+		}
+		for _, ve := range tc.want {
+			if !errors.Is(err, ve.Err) {
+				t.Fatalf("%s, test:%d %s !errors.Is(err, %v)", t.Name(), i, tc.name, ve.Err)
+			}
+		}
+	}
+
+	err := validateTimeWindows(TimeWindows{AW: time.Second, BW: 0, AB: time.Second, BB: -1, Rate: -1})
+	want := []ValidationError{
+		{Field: "Time.BW", Value: time.Duration(0), Min: time.Nanosecond, Err: ErrTimeWindowBW},
+		{Field: "Time.BB", Value: time.Duration(-1), Min: time.Nanosecond, Err: ErrTimeWindowBB},
+		{Field: "Time.Rate", Value: -1.0, Min: 0.0, Err: ErrTimeWindowRate},
+	}
+	if ves := validationErrors(err); !reflect.DeepEqual(ves, want) {
+		t.Fatalf("%s, time errors:%+v != want:%+v", t.Name(), ves, want)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("%s, validation logged:%q", t.Name(), buf.String())
+	}
+
+	ve := ValidationError{Field: "AW", Value: uint16(3), Min: uint16(4), Max: uint16(MaxWindowCst), Err: ErrWindowAWMin}
+	if ve.Error() != "AW:3, min:4, max:1500, ErrWindow window ahead min" {
+		t.Fatalf("%s, Error():%q", t.Name(), ve.Error())
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/randomizedcoder/goTrackRTP"
 
@@ -23,6 +24,11 @@ const (
 
 	signalChannelSize = 10
 
+	intervalCst = 10 * time.Second
+
+	idleTimeoutCst = time.Minute
+	maxStreamsCst  = 1000
+
 	awCst = 100
 	bwCst = 100
 	abCst = 100
@@ -41,7 +47,7 @@ func main() {
 
 	log.Println("goTrackingRTPer")
 
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	go initSignalHandler(cancel)
@@ -58,6 +64,17 @@ func main() {
 
 	dl := flag.Int("dl", debugLevelCst, "nasty debugLevel")
 
+	pcapFile := flag.String("pcap", "", "pcap or pcapng file to replay, instead of the random sequences")
+	port := flag.Int("port", 0, "pcap UDP port filter, source or destination, zero (0) for all")
+	ssrc := flag.Uint64("ssrc", 0, "pcap RTP SSRC filter, zero (0) for all")
+	idle := flag.Duration("idle", idleTimeoutCst, "pcap and listen, streams idle for longer are expired, zero (0) disables")
+	maxStreams := flag.Int("streams", maxStreamsCst, "pcap and listen, maximum number of streams, zero (0) is unlimited")
+
+	listen := flag.String("listen", "", "addr:port to receive RTP on, unicast or multicast, instead of the random sequences")
+	iface := flag.String("iface", "", "listen multicast interface, empty for the system default")
+	interval := flag.Duration("interval", intervalCst, "listen stats printing interval")
+	prom := flag.String("prom", "", "listen Prometheus /metrics addr:port, e.g. :9100, empty to disable")
+
 	flag.Parse()
 
 	if *version {
@@ -65,6 +82,45 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *pcapFile != "" {
+		config := goTrackRTP.ManagerConfig{
+			AW:          uint16(*aw),
+			BW:          uint16(*bw),
+			AB:          uint16(*ab),
+			BB:          uint16(*bb),
+			DebugLevel:  *dl,
+			IdleTimeout: *idle,
+			MaxStreams:  *maxStreams,
+		}
+		err := runPcap(*pcapFile, config, pcapFilter{port: *port, ssrc: *ssrc}, os.Stdout)
+		if err != nil {
+			log.Fatal("runPcap:", err)
+		}
+		return
+	}
+
+	if *listen != "" {
+		config := listenConfig{
+			aw:         uint16(*aw),
+			bw:         uint16(*bw),
+			ab:         uint16(*ab),
+			bb:         uint16(*bb),
+			debugLevel: *dl,
+			addr:       *listen,
+			iface:      *iface,
+			interval:   *interval,
+			prom:       *prom,
+
+			idleTimeout: *idle,
+			maxStreams:  *maxStreams,
+		}
+		err := runListen(ctx, config, os.Stdout)
+		if err != nil {
+			log.Fatal("runListen:", err)
+		}
+		return
+	}
+
 	tr, err := goTrackRTP.New(uint16(*aw), uint16(*bw), uint16(*ab), uint16(*bb), *dl)
 	if err != nil {
 		log.Fatal("goTrackRTP.New:", err)
@@ -74,7 +130,7 @@ func main() {
 
 	var s uint16
 	var seq uint16
-	for i := 0; i < *loops; i++ {
+	for i := 0; i < *loops && ctx.Err() == nil; i++ {
 
 		r := uint16(FastRandN(uint32(*randn)) + 1) // FastRandN can return zero (0)
 		p := FastRandN(2)
@@ -98,7 +154,8 @@ func main() {
 }
 
 // initSignalHandler sets up signal handling for the process, and
-// will call cancel() when recieved
+// will call cancel() when recieved, so the listen mode can print the
+// final stats.  A second signal exits immediately.
 func initSignalHandler(cancel context.CancelFunc) {
 	c := make(chan os.Signal, signalChannelSize)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
@@ -106,6 +163,8 @@ func initSignalHandler(cancel context.CancelFunc) {
 	<-c
 	log.Printf("Signal caught, closing application")
 	cancel()
+
+	<-c
 	os.Exit(0)
 }
 
@@ -0,0 +1,242 @@
+package main
+
+// listen receives RTP over UDP, unicast or multicast, with a
+// goTrackRTP.Manager tracking each stream with a SafeTracker, periodically
+// printing the stats, and optionally exporting them as Prometheus metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/randomizedcoder/goTrackRTP"
+	"github.com/randomizedcoder/goTrackRTP/pcap"
+	"github.com/randomizedcoder/goTrackRTP/promexporter"
+	"github.com/randomizedcoder/goTrackRTP/rtp"
+)
+
+const (
+	readBufferSizeCst = 64 * 1024
+)
+
+// listenConfig configures the listen mode
+type listenConfig struct {
+	aw, bw, ab, bb uint16
+	debugLevel     int
+
+	addr     string        // addr:port to listen on, which may be a multicast group
+	iface    string        // interface for the multicast join, empty for the system default
+	interval time.Duration // stats printing interval, zero (0) to only print on exit
+	prom     string        // addr:port for the Prometheus /metrics endpoint, empty to disable
+
+	idleTimeout time.Duration // streams idle for longer are expired, zero (0) disables
+	maxStreams  int           // maximum number of streams, zero (0) is unlimited
+}
+
+// listener receives the packets
+// The Manager is only used from the run goroutine, while the SafeTrackers
+// are also read by the Prometheus scrapes
+type listener struct {
+	config    listenConfig
+	conn      *net.UDPConn
+	m         *goTrackRTP.Manager
+	collector *promexporter.Collector
+	dst       string
+
+	packets atomic.Uint64 // datagrams received, including those which are not RTP
+}
+
+// newListener opens the socket, joining the group for multicast addresses
+func newListener(config listenConfig) (*listener, error) {
+
+	addr, err := net.ResolveUDPAddr("udp", config.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &listener{
+		config:    config,
+		collector: promexporter.New(promexporter.Opts{}),
+	}
+
+	// The 5-tuple keeps streams apart which happen to use the same SSRC,
+	// and expired streams stop being exported
+	l.m, err = goTrackRTP.NewManager(goTrackRTP.ManagerConfig{
+		AW:             config.aw,
+		BW:             config.bw,
+		AB:             config.ab,
+		BB:             config.bb,
+		DebugLevel:     config.debugLevel,
+		KeyByFiveTuple: true,
+		Safe:           true,
+		IdleTimeout:    config.idleTimeout,
+		MaxStreams:     config.maxStreams,
+		OnExpire: func(s *goTrackRTP.Stream) {
+			l.collector.Remove(promStream(s))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var conn *net.UDPConn
+	if addr.IP.IsMulticast() {
+		var ifi *net.Interface
+		if config.iface != "" {
+			ifi, err = net.InterfaceByName(config.iface)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// ListenMulticastUDP sends the IGMP/MLD join
+		conn, err = net.ListenMulticastUDP("udp", ifi, addr)
+	} else {
+		conn, err = net.ListenUDP("udp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	l.conn = conn
+	l.dst = conn.LocalAddr().String()
+
+	return l, nil
+}
+
+// run reads packets until the context is cancelled, printing the stats
+// every interval, and on exit
+func (l *listener) run(ctx context.Context, w io.Writer) error {
+
+	go func() {
+		<-ctx.Done()
+		l.conn.Close()
+	}()
+
+	var next time.Time
+	if l.config.interval > 0 {
+		next = time.Now().Add(l.config.interval)
+	}
+
+	buf := make([]byte, readBufferSizeCst)
+	var p rtp.Packet
+
+	for {
+		// The deadline wakes up the read for the printing, so the Manager
+		// is only used from this goroutine
+		err := l.conn.SetReadDeadline(next)
+		if err != nil {
+			return err
+		}
+
+		n, src, err := l.conn.ReadFromUDPAddrPort(buf)
+		now := time.Now()
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				l.print(w, now)
+				next = now.Add(l.config.interval)
+				continue
+			}
+			if ctx.Err() != nil {
+				l.print(w, now)
+				return nil
+			}
+			return err
+		}
+
+		l.packets.Add(1)
+
+		// dual stack sockets return IPv4 sources as IPv4-mapped IPv6
+		src = netip.AddrPortFrom(src.Addr().Unmap(), src.Port())
+
+		err = p.Parse(buf[:n])
+		if err != nil {
+			if l.config.debugLevel > 10 {
+				log.Printf("run src:%s Parse err:%v", src, err)
+			}
+			continue
+		}
+
+		err = l.packetArrival(p.SSRC, p.SequenceNumber, src, now)
+		if err != nil && l.config.debugLevel > 10 {
+			log.Printf("run src:%s ssrc:%d seq:%d err:%v", src, p.SSRC, p.SequenceNumber, err)
+		}
+	}
+}
+
+// packetArrival demuxes by SSRC and source, exporting the new streams
+func (l *listener) packetArrival(ssrc uint32, seq uint16, src netip.AddrPort, now time.Time) error {
+
+	key := l.m.Key(ssrc, goTrackRTP.FiveTuple{Src: src, Proto: pcap.ProtoUDP})
+
+	_, ok := l.m.Stream(key)
+
+	_, err := l.m.PacketArrival(key, seq, now)
+
+	if !ok {
+		if s, added := l.m.Stream(key); added {
+			l.collector.Add(promStream(s), s.Safe)
+		}
+	}
+
+	return err
+}
+
+// promStream is the exported stream, labelled by the source
+func promStream(s *goTrackRTP.Stream) promexporter.Stream {
+	return promexporter.Stream{SSRC: s.Key.SSRC, Name: s.Key.FiveTuple.Src.String()}
+}
+
+// print prints the stats table, expiring the idle streams first
+func (l *listener) print(w io.Writer, now time.Time) {
+
+	l.m.Expire(now)
+
+	rows := make([]streamRow, 0, l.m.Len())
+	l.m.Range(func(s *goTrackRTP.Stream) bool {
+		row := newStreamRow(s)
+		row.dst = l.dst
+		rows = append(rows, row)
+		return true
+	})
+
+	fmt.Fprintf(w, "%s packets:%d streams:%d\n", now.Format(time.RFC3339), l.packets.Load(), len(rows))
+	printStreams(w, rows)
+}
+
+// runListen runs the listen mode, serving the Prometheus metrics if configured
+func runListen(ctx context.Context, config listenConfig, w io.Writer) error {
+
+	l, err := newListener(config)
+	if err != nil {
+		return err
+	}
+
+	if config.prom != "" {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(l.collector)
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+		go func() {
+			err := http.ListenAndServe(config.prom, mux)
+			if err != nil {
+				log.Fatal("http.ListenAndServe:", err)
+			}
+		}()
+	}
+
+	log.Printf("listening on %s", l.dst)
+
+	return l.run(ctx, w)
+}
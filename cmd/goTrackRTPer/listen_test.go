@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+// rtpHeader builds a minimal RTP packet
+func rtpHeader(ssrc uint32, seq uint16) []byte {
+	b := make([]byte, 12)
+	b[0] = 0x80
+	b[1] = 96
+	binary.BigEndian.PutUint16(b[2:4], seq)
+	binary.BigEndian.PutUint32(b[8:12], ssrc)
+	return b
+}
+
+func TestListen(t *testing.T) {
+
+	l, err := newListener(listenConfig{
+		aw: 10, bw: 10, ab: 10, bb: 10,
+		addr:     "127.0.0.1:0",
+		interval: time.Hour, // only the final print
+	})
+	if err != nil {
+		t.Fatalf("%s, newListener err:%v", t.Name(), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- l.run(ctx, &out)
+	}()
+
+	conn, err := net.Dial("udp", l.conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("%s, Dial err:%v", t.Name(), err)
+	}
+	defer conn.Close()
+
+	type send struct {
+		ssrc uint32
+		seq  uint16
+	}
+
+	sends := []send{
+		{1, 1}, {1, 2}, {1, 4}, {1, 4}, {1, 5},
+		{2, 100}, {2, 102}, {2, 101},
+	}
+
+	for _, s := range sends {
+		_, err := conn.Write(rtpHeader(s.ssrc, s.seq))
+		if err != nil {
+			t.Fatalf("%s, Write err:%v", t.Name(), err)
+		}
+	}
+	// not RTP
+	_, err = conn.Write([]byte{1, 2, 3})
+	if err != nil {
+		t.Fatalf("%s, Write err:%v", t.Name(), err)
+	}
+
+	// loopback delivery is asynchronous, so wait for the packets to be read
+	deadline := time.Now().Add(5 * time.Second)
+	for l.packets.Load() < uint64(len(sends)+1) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s, timeout waiting for the packets", t.Name())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	err = <-done
+	if err != nil {
+		t.Fatalf("%s, run err:%v", t.Name(), err)
+	}
+
+	t.Log(out.String())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	// streams line, header, and a line per stream
+	if len(lines) != 4 || !strings.HasSuffix(lines[0], "packets:9 streams:2") {
+		t.Fatalf("%s, lines:%q", t.Name(), lines)
+	}
+
+	type test struct {
+		line   int
+		fields []string // ssrc, packets, lost, missing, reordered, late, duplicates
+	}
+
+	tests := []test{
+		{2, []string{"0x00000001", "5", "0", "1", "0", "0", "1"}},
+		{3, []string{"0x00000002", "3", "0", "0", "1", "0", "0"}},
+	}
+
+	for i, tc := range tests {
+		f := strings.Fields(lines[tc.line])
+		got := []string{f[0], f[3], f[4], f[5], f[6], f[7], f[8]}
+		if strings.Join(got, " ") != strings.Join(tc.fields, " ") {
+			t.Fatalf("%s, test:%d got:%v != tc.fields:%v", t.Name(), i, got, tc.fields)
+		}
+	}
+}
+
+func TestListenExpire(t *testing.T) {
+
+	l, err := newListener(listenConfig{
+		aw: 10, bw: 10, ab: 10, bb: 10,
+		addr:        "127.0.0.1:0",
+		idleTimeout: time.Second,
+		maxStreams:  2,
+	})
+	if err != nil {
+		t.Fatalf("%s, newListener err:%v", t.Name(), err)
+	}
+	defer l.conn.Close()
+
+	src := netip.MustParseAddrPort("192.0.2.1:4000")
+	start := time.Unix(1700000000, 0)
+
+	type test struct {
+		name   string
+		ssrc   uint32
+		offset time.Duration
+		err    error
+		Len    int // streams exported
+	}
+
+	tests := []test{
+		{"first", 1, 0, nil, 1},
+		{"second", 2, 0, nil, 2},
+		{"max streams", 3, 0, goTrackRTP.ErrMaxStreams, 2},
+		// 1 and 2 are idle, so are expired to make room
+		{"expires idle", 3, 2 * time.Second, nil, 1},
+	}
+
+	for i, tc := range tests {
+		err := l.packetArrival(tc.ssrc, 1, src, start.Add(tc.offset))
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+		if l.collector.Len() != tc.Len {
+			t.Fatalf("%s, test:%d %s collector.Len():%d != tc.Len:%d", t.Name(), i, tc.name, l.collector.Len(), tc.Len)
+		}
+	}
+
+	// printing expires the idle streams
+	var out bytes.Buffer
+	l.print(&out, start.Add(time.Hour))
+	if l.m.Len() != 0 || l.collector.Len() != 0 {
+		t.Fatalf("%s, m.Len():%d collector.Len():%d after expiry", t.Name(), l.m.Len(), l.collector.Len())
+	}
+}
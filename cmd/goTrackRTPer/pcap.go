@@ -0,0 +1,129 @@
+package main
+
+// pcap replays a pcap or pcapng capture through a goTrackRTP.Manager, and
+// prints a per-stream summary
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/randomizedcoder/goTrackRTP"
+	"github.com/randomizedcoder/goTrackRTP/pcap"
+	"github.com/randomizedcoder/goTrackRTP/rtp"
+)
+
+// pcapFilter selects the packets to track, zero (0) matches everything
+type pcapFilter struct {
+	port int
+	ssrc uint64
+}
+
+// pcapCounts are the packets which were not tracked
+type pcapCounts struct {
+	packets  uint64
+	notUDP   uint64
+	filtered uint64
+	notRTP   uint64
+	errors   uint64
+}
+
+// runPcap reads the capture, feeding each RTP packet to the tracker for its stream
+func runPcap(filename string, config goTrackRTP.ManagerConfig, filter pcapFilter, w io.Writer) error {
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := pcap.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	// The 5-tuple keeps streams apart which happen to use the same SSRC
+	config.KeyByFiveTuple = true
+
+	// streams expired during the replay are still reported
+	var expired []streamRow
+	config.OnExpire = func(s *goTrackRTP.Stream) {
+		expired = append(expired, newStreamRow(s))
+	}
+
+	m, err := goTrackRTP.NewManager(config)
+	if err != nil {
+		return err
+	}
+
+	var (
+		c pcapCounts
+		p rtp.Packet
+	)
+
+	for {
+		pkt, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		c.packets++
+
+		udp, err := pcap.DecodeUDP(pkt.LinkType, pkt.Data)
+		if err != nil {
+			c.notUDP++
+			continue
+		}
+
+		if filter.port != 0 && int(udp.Src.Port()) != filter.port && int(udp.Dst.Port()) != filter.port {
+			c.filtered++
+			continue
+		}
+
+		err = p.Parse(udp.Payload)
+		if err != nil {
+			c.notRTP++
+			continue
+		}
+
+		if filter.ssrc != 0 && uint64(p.SSRC) != filter.ssrc {
+			c.filtered++
+			continue
+		}
+
+		ft := goTrackRTP.FiveTuple{Src: udp.Src, Dst: udp.Dst, Proto: pcap.ProtoUDP}
+
+		// The capture timestamps are used, so the idle timeout works in capture time
+		_, err = m.PacketArrival(m.Key(p.SSRC, ft), p.SequenceNumber, pkt.Timestamp)
+		if err != nil {
+			if config.DebugLevel > 10 {
+				log.Printf("runPcap PacketArrival ssrc:%d seq:%d err:%v", p.SSRC, p.SequenceNumber, err)
+			}
+			c.errors++
+		}
+	}
+
+	printPcapSummary(w, m, expired, c)
+
+	return nil
+}
+
+// printPcapSummary prints the counts, and then a line per stream, including
+// the expired streams, sorted by SSRC
+func printPcapSummary(w io.Writer, m *goTrackRTP.Manager, expired []streamRow, c pcapCounts) {
+
+	rows := expired
+	m.Range(func(s *goTrackRTP.Stream) bool {
+		rows = append(rows, newStreamRow(s))
+		return true
+	})
+
+	fmt.Fprintf(w, "packets:%d notUDP:%d filtered:%d notRTP:%d errors:%d streams:%d expired:%d\n",
+		c.packets, c.notUDP, c.filtered, c.notRTP, c.errors, len(rows), len(expired))
+
+	printStreams(w, rows)
+}
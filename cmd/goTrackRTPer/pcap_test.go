@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+	"github.com/randomizedcoder/goTrackRTP/pcap"
+)
+
+// rawRTP builds an IPv4/UDP/RTP packet for the raw IP link type
+func rawRTP(dstPort uint16, ssrc uint32, seq uint16) []byte {
+
+	b := make([]byte, 20+8+12)
+
+	// IPv4
+	b[0] = 0x45
+	binary.BigEndian.PutUint16(b[2:4], uint16(len(b)))
+	b[9] = pcap.ProtoUDP
+	copy(b[12:16], []byte{10, 0, 0, 1})
+	copy(b[16:20], []byte{10, 0, 0, 2})
+
+	// UDP
+	binary.BigEndian.PutUint16(b[20:22], 4000)
+	binary.BigEndian.PutUint16(b[22:24], dstPort)
+	binary.BigEndian.PutUint16(b[24:26], uint16(len(b)-20))
+
+	// RTP
+	b[28] = 0x80
+	b[29] = 96
+	binary.BigEndian.PutUint16(b[30:32], seq)
+	binary.BigEndian.PutUint32(b[36:40], ssrc)
+
+	return b
+}
+
+// writeCapture writes a little endian classic pcap file
+func writeCapture(t *testing.T, pkts [][]byte) string {
+
+	var b bytes.Buffer
+	h := make([]byte, 24)
+	binary.LittleEndian.PutUint32(h[0:4], 0xA1B2C3D4)
+	binary.LittleEndian.PutUint16(h[4:6], 2)
+	binary.LittleEndian.PutUint16(h[6:8], 4)
+	binary.LittleEndian.PutUint32(h[16:20], 65535)
+	binary.LittleEndian.PutUint32(h[20:24], pcap.LinkTypeRaw)
+	b.Write(h)
+
+	for i, p := range pkts {
+		r := make([]byte, 16)
+		binary.LittleEndian.PutUint32(r[0:4], uint32(1700000000+i))
+		binary.LittleEndian.PutUint32(r[8:12], uint32(len(p)))
+		binary.LittleEndian.PutUint32(r[12:16], uint32(len(p)))
+		b.Write(r)
+		b.Write(p)
+	}
+
+	filename := filepath.Join(t.TempDir(), "test.pcap")
+	err := os.WriteFile(filename, b.Bytes(), 0o600)
+	if err != nil {
+		t.Fatalf("%s, WriteFile err:%v", t.Name(), err)
+	}
+
+	return filename
+}
+
+func TestRunPcap(t *testing.T) {
+
+	var pkts [][]byte
+	// stream 1 with a gap at 3, and a duplicate 5
+	for _, seq := range []uint16{1, 2, 4, 5, 5, 6} {
+		pkts = append(pkts, rawRTP(5004, 1, seq))
+	}
+	// stream 2 with 12 and 11 reordered
+	for _, seq := range []uint16{10, 12, 11, 13} {
+		pkts = append(pkts, rawRTP(5004, 2, seq))
+	}
+	// another port, and not RTP
+	pkts = append(pkts, rawRTP(6000, 3, 1))
+	pkts = append(pkts, rawRTP(5004, 4, 1)[:30])
+
+	filename := writeCapture(t, pkts)
+
+	config := goTrackRTP.ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10}
+
+	var out bytes.Buffer
+	err := runPcap(filename, config, pcapFilter{port: 5004}, &out)
+	if err != nil {
+		t.Fatalf("%s, runPcap err:%v", t.Name(), err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	t.Log(out.String())
+
+	if lines[0] != "packets:12 notUDP:0 filtered:1 notRTP:1 errors:0 streams:2 expired:0" {
+		t.Fatalf("%s, counts line:%q", t.Name(), lines[0])
+	}
+
+	// header, and a line per stream
+	if len(lines) != 4 {
+		t.Fatalf("%s, len(lines):%d != 4", t.Name(), len(lines))
+	}
+
+	type test struct {
+		line   int
+		fields []string // ssrc, packets, lost, missing, reordered, late, duplicates
+	}
+
+	tests := []test{
+		{2, []string{"0x00000001", "6", "0", "1", "0", "0", "1"}},
+		{3, []string{"0x00000002", "4", "0", "0", "1", "0", "0"}},
+	}
+
+	for i, tc := range tests {
+		f := strings.Fields(lines[tc.line])
+		got := []string{f[0], f[3], f[4], f[5], f[6], f[7], f[8]}
+		if strings.Join(got, " ") != strings.Join(tc.fields, " ") {
+			t.Fatalf("%s, test:%d got:%v != tc.fields:%v", t.Name(), i, got, tc.fields)
+		}
+	}
+}
+
+func TestRunPcapExpired(t *testing.T) {
+
+	// writeCapture spaces the packets a second apart, so stream 1 is idle
+	// for longer than the timeout, and expired to make room for stream 3
+	var pkts [][]byte
+	for _, seq := range []uint16{1, 2, 4} {
+		pkts = append(pkts, rawRTP(5004, 1, seq))
+	}
+	for _, seq := range []uint16{10, 11, 12, 13} {
+		pkts = append(pkts, rawRTP(5004, 2, seq))
+	}
+	pkts = append(pkts, rawRTP(5004, 3, 20))
+
+	filename := writeCapture(t, pkts)
+
+	config := goTrackRTP.ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10, IdleTimeout: 2 * time.Second, MaxStreams: 2}
+
+	var out bytes.Buffer
+	err := runPcap(filename, config, pcapFilter{}, &out)
+	if err != nil {
+		t.Fatalf("%s, runPcap err:%v", t.Name(), err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	t.Log(out.String())
+
+	if lines[0] != "packets:8 notUDP:0 filtered:0 notRTP:0 errors:0 streams:3 expired:1" {
+		t.Fatalf("%s, counts line:%q", t.Name(), lines[0])
+	}
+
+	// header, and a line per stream
+	if len(lines) != 5 {
+		t.Fatalf("%s, len(lines):%d != 5", t.Name(), len(lines))
+	}
+
+	// the expired stream keeps its final stats
+	f := strings.Fields(lines[2])
+	got := []string{f[0], f[3], f[4], f[5]}
+	if strings.Join(got, " ") != "0x00000001 3 0 1" {
+		t.Fatalf("%s, expired stream got:%v", t.Name(), got)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+// streams prints the per-stream statistics table, shared by the pcap and listen modes
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+// streamRow is a line of the statistics table
+type streamRow struct {
+	ssrc     uint32
+	src      string
+	dst      string
+	stats    goTrackRTP.Stats
+	rfc      goTrackRTP.RFC3550
+	missing  int
+	duration time.Duration
+}
+
+// rowSource is implemented by both the Tracker, and the SafeTracker
+type rowSource interface {
+	Stats() goTrackRTP.Stats
+	RFC3550() goTrackRTP.RFC3550
+	Missing() []uint16
+}
+
+// newStreamRow returns the line for the Manager's stream
+func newStreamRow(s *goTrackRTP.Stream) streamRow {
+
+	var src rowSource = s.Tracker
+	if s.Safe != nil {
+		src = s.Safe
+	}
+
+	return streamRow{
+		ssrc:     s.Key.SSRC,
+		src:      s.Key.FiveTuple.Src.String(),
+		dst:      s.Key.FiveTuple.Dst.String(),
+		stats:    src.Stats(),
+		rfc:      src.RFC3550(),
+		missing:  len(src.Missing()),
+		duration: s.LastSeen.Sub(s.FirstSeen),
+	}
+}
+
+// printStreams prints a line per stream, sorted by SSRC
+// lost fell off the back of the window, while missing are the gaps still
+// within the window
+func printStreams(w io.Writer, rows []streamRow) {
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].ssrc != rows[j].ssrc {
+			return rows[i].ssrc < rows[j].ssrc
+		}
+		if rows[i].src != rows[j].src {
+			return rows[i].src < rows[j].src
+		}
+		return rows[i].dst < rows[j].dst
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "ssrc\tsrc\tdst\tpackets\tlost\tmissing\treordered\tlate\tduplicates\trestarts\tbuffer\trfc3550 lost\tduration\t")
+
+	for _, r := range rows {
+		fmt.Fprintf(tw, "0x%08x\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t\n",
+			r.ssrc, r.src, r.dst,
+			r.stats.Packets, r.stats.Lost, r.missing, r.stats.Reordered(), r.stats.Late(), r.stats.Duplicates(),
+			r.stats.Restarts(), r.stats.Buffer(), r.rfc.Lost(), r.duration)
+	}
+
+	tw.Flush()
+}
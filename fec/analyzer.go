@@ -0,0 +1,286 @@
+package fec
+
+// Analyzer reports how many of the media losses are recoverable by the FEC
+//
+// The media sequence numbers come from the Tracker, and the FEC packets
+// received are recorded with Packet().  Each matrix is analyzed once, when
+// the media stream is a whole matrix past the end of it, as the FEC
+// packets for a matrix are sent during the following matrix.
+//
+// Each matrix is analyzed twice, with the FEC packets which were received,
+// and as if all the FEC packets were received, so it's clear whether the
+// losses are beyond the L x D matrix, or the FEC packets were lost too.
+
+import (
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+// MediaSource provides the received media sequence numbers
+// goTrackRTP.Tracker and goTrackRTP.SafeTracker implement MediaSource
+type MediaSource interface {
+	MissingRanges() []goTrackRTP.SeqRange
+	Min() uint16
+	Max() uint16
+}
+
+// Config is the FEC matrix
+type Config struct {
+	L    int    // columns
+	D    int    // rows
+	Rows bool   // row FEC as well as column FEC
+	Base uint16 // the first media sequence number of any matrix, the following matrices are consecutive
+}
+
+// Matrix is the analysis of a single matrix
+type Matrix struct {
+	Base          uint16 // first media sequence number
+	Lost          int    // media packets not received
+	Recovered     int    // recoverable using the FEC packets received
+	Unrecoverable int    // Lost - Recovered
+	Potential     int    // recoverable if all the FEC packets were received
+	ColumnFEC     int    // column FEC packets received
+	RowFEC        int    // row FEC packets received
+}
+
+// Totals sums the matrices analyzed
+type Totals struct {
+	Matrices      uint64
+	Skipped       uint64 // matrices which fell off the back of the window before being analyzed
+	Lost          uint64
+	Recovered     uint64
+	Unrecoverable uint64
+	Potential     uint64
+}
+
+// Analyzer is the FEC state for a single media stream
+type Analyzer struct {
+	config Config
+
+	columns map[uint16]struct{} // SNBase of the column FEC packets received
+	rows    map[uint16]struct{} // SNBase of the row FEC packets received
+
+	started bool
+	next    uint16 // base of the next matrix to analyze
+	totals  Totals
+}
+
+// validateConfig checks the matrix is within the ST 2022-1 limits
+func validateConfig(c Config) error {
+
+	if c.L < MinLCst || (c.Rows && c.L < MinRowLCst) {
+		return ErrLMin
+	}
+	if c.L > MaxLCst {
+		return ErrLMax
+	}
+	if c.D < MinDCst {
+		return ErrDMin
+	}
+	if c.D > MaxDCst {
+		return ErrDMax
+	}
+	if c.L*c.D > MaxLDCst {
+		return ErrLDMax
+	}
+
+	return nil
+}
+
+// NewAnalyzer creates an Analyzer for the matrix
+func NewAnalyzer(config Config) (*Analyzer, error) {
+
+	err := validateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Analyzer{
+		config:  config,
+		columns: make(map[uint16]struct{}),
+		rows:    make(map[uint16]struct{}),
+	}, nil
+}
+
+// Packet records a FEC packet received on the column, or row, FEC stream
+// Returns ErrMatrix if the Offset and NA don't match the matrix
+func (a *Analyzer) Packet(h Header) error {
+
+	if h.Row {
+		if int(h.Offset) != 1 || int(h.NA) != a.config.L {
+			return ErrMatrix
+		}
+		a.rows[h.SNBase] = struct{}{}
+		return nil
+	}
+
+	if int(h.Offset) != a.config.L || int(h.NA) != a.config.D {
+		return ErrMatrix
+	}
+	a.columns[h.SNBase] = struct{}{}
+
+	return nil
+}
+
+// Analyze analyzes the matrices which are now complete, returning them in order
+func (a *Analyzer) Analyze(src MediaSource) (matrices []Matrix) {
+
+	n := uint16(a.config.L * a.config.D)
+	min := src.Min()
+	max := src.Max()
+
+	// the first matrix is the first after Base, which is at or after Min()
+	if !a.started {
+		a.started = true
+		k := (int(min-a.config.Base) + int(n) - 1) / int(n)
+		a.next = a.config.Base + uint16(k*int(n))
+	}
+
+	// skip the matrices which have fallen off the back of the window
+	for goTrackRTP.IsLess(a.next, min) {
+		a.next += n
+		a.totals.Skipped++
+	}
+
+	// the FEC for a matrix is sent during the following matrix
+	if !goTrackRTP.IsLess(a.next+2*n-1, max+1) {
+		return nil
+	}
+
+	lost := make(map[uint16]struct{})
+	for _, r := range src.MissingRanges() {
+		for i, seq := 0, r.Start; i < r.Len(); i, seq = i+1, seq+1 {
+			lost[seq] = struct{}{}
+		}
+	}
+
+	for goTrackRTP.IsLess(a.next+2*n-1, max+1) {
+		m := a.matrix(a.next, lost)
+		matrices = append(matrices, m)
+
+		a.totals.Matrices++
+		a.totals.Lost += uint64(m.Lost)
+		a.totals.Recovered += uint64(m.Recovered)
+		a.totals.Unrecoverable += uint64(m.Unrecoverable)
+		a.totals.Potential += uint64(m.Potential)
+
+		a.next += n
+	}
+
+	a.prune()
+
+	return matrices
+}
+
+// matrix analyzes the matrix starting at base
+func (a *Analyzer) matrix(base uint16, lost map[uint16]struct{}) Matrix {
+
+	L, D := a.config.L, a.config.D
+
+	missing := make([]bool, L*D)
+	m := Matrix{Base: base}
+	for i := range missing {
+		if _, ok := lost[base+uint16(i)]; ok {
+			missing[i] = true
+			m.Lost++
+		}
+	}
+
+	columns := make([]bool, L)
+	for c := range columns {
+		_, columns[c] = a.columns[base+uint16(c)]
+		if columns[c] {
+			m.ColumnFEC++
+		}
+	}
+	rows := make([]bool, D)
+	for r := range rows {
+		if a.config.Rows {
+			_, rows[r] = a.rows[base+uint16(r*L)]
+		}
+		if rows[r] {
+			m.RowFEC++
+		}
+	}
+
+	m.Recovered = a.recover(append([]bool(nil), missing...), columns, rows)
+
+	for c := range columns {
+		columns[c] = true
+	}
+	for r := range rows {
+		rows[r] = a.config.Rows
+	}
+	m.Potential = a.recover(missing, columns, rows)
+
+	m.Unrecoverable = m.Lost - m.Recovered
+
+	return m
+}
+
+// recover applies the column, and row, FEC in turn, until no more packets
+// are recovered, and returns the number recovered
+func (a *Analyzer) recover(missing []bool, columns []bool, rows []bool) (recovered int) {
+
+	L, D := a.config.L, a.config.D
+
+	for progress := true; progress; {
+		progress = false
+
+		for c := 0; c < L; c++ {
+			if !columns[c] {
+				continue
+			}
+			count, at := 0, 0
+			for r := 0; r < D; r++ {
+				if missing[r*L+c] {
+					count++
+					at = r*L + c
+				}
+			}
+			if count == 1 {
+				missing[at] = false
+				recovered++
+				progress = true
+			}
+		}
+
+		for r := 0; r < D; r++ {
+			if !rows[r] {
+				continue
+			}
+			count, at := 0, 0
+			for c := 0; c < L; c++ {
+				if missing[r*L+c] {
+					count++
+					at = r*L + c
+				}
+			}
+			if count == 1 {
+				missing[at] = false
+				recovered++
+				progress = true
+			}
+		}
+	}
+
+	return recovered
+}
+
+// prune forgets the FEC packets for the matrices already analyzed
+func (a *Analyzer) prune() {
+	for sn := range a.columns {
+		if goTrackRTP.IsLess(sn, a.next) {
+			delete(a.columns, sn)
+		}
+	}
+	for sn := range a.rows {
+		if goTrackRTP.IsLess(sn, a.next) {
+			delete(a.rows, sn)
+		}
+	}
+}
+
+// Totals returns the sums of the matrices analyzed
+func (a *Analyzer) Totals() Totals {
+	return a.totals
+}
@@ -0,0 +1,121 @@
+package fec
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+func TestValidateConfig(t *testing.T) {
+
+	type test struct {
+		name   string
+		config Config
+		err    error
+	}
+
+	tests := []test{
+		{"ok", Config{L: 10, D: 10, Rows: true}, nil},
+		{"column only", Config{L: 1, D: 4}, nil},
+		{"l min", Config{L: 0, D: 4}, ErrLMin},
+		{"row l min", Config{L: 3, D: 4, Rows: true}, ErrLMin},
+		{"l max", Config{L: 21, D: 4}, ErrLMax},
+		{"d min", Config{L: 4, D: 3}, ErrDMin},
+		{"d max", Config{L: 4, D: 21}, ErrDMax},
+		{"ld max", Config{L: 11, D: 10}, ErrLDMax},
+	}
+
+	for i, tc := range tests {
+		_, err := NewAnalyzer(tc.config)
+		if err != tc.err {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestAnalyzer(t *testing.T) {
+
+	tr, err := goTrackRTP.New(100, 100, 10, 10, 0)
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+
+	// 4 x 4, so the matrices start at 0, 16, 32, and 48
+	a, err := NewAnalyzer(Config{L: 4, D: 4, Rows: true})
+	if err != nil {
+		t.Fatalf("%s, NewAnalyzer err:%v", t.Name(), err)
+	}
+
+	// 5 and 6 are in the same row, and 16, 17, 20, and 21 are a square, and 40 has no FEC
+	lost := map[uint16]bool{5: true, 6: true, 16: true, 17: true, 20: true, 21: true, 40: true}
+	arrive := func(from, to uint16) {
+		for seq := from; seq <= to; seq++ {
+			if lost[seq] {
+				continue
+			}
+			_, e := tr.PacketArrival(seq)
+			if e != nil {
+				t.Fatalf("%s, PacketArrival err:%v", t.Name(), e)
+			}
+		}
+	}
+
+	packet := func(h Header) {
+		if e := a.Packet(h); e != nil {
+			t.Fatalf("%s, Packet err:%v", t.Name(), e)
+		}
+	}
+
+	// the first matrix is missing the column 2 FEC, and all but the row 1 FEC
+	for _, c := range []uint16{0, 1, 3} {
+		packet(Header{SNBase: c, Offset: 4, NA: 4})
+	}
+	packet(Header{SNBase: 4, Row: true, Offset: 1, NA: 4})
+
+	// the second matrix has all the FEC
+	for i := uint16(0); i < 4; i++ {
+		packet(Header{SNBase: 16 + i, Offset: 4, NA: 4})
+		packet(Header{SNBase: 16 + i*4, Row: true, Offset: 1, NA: 4})
+	}
+
+	if e := a.Packet(Header{SNBase: 32, Offset: 5, NA: 4}); e != ErrMatrix {
+		t.Fatalf("%s, Packet wrong offset err:%v != ErrMatrix", t.Name(), e)
+	}
+
+	// the second matrix isn't complete until 47
+	arrive(0, 46)
+	matrices := a.Analyze(tr)
+	// column 1 recovers 5, and then the row 1 FEC recovers 6
+	want := []Matrix{{Base: 0, Lost: 2, Recovered: 2, Unrecoverable: 0, Potential: 2, ColumnFEC: 3, RowFEC: 1}}
+	if !reflect.DeepEqual(matrices, want) {
+		t.Fatalf("%s, Analyze():%+v != want:%+v", t.Name(), matrices, want)
+	}
+
+	arrive(47, 63)
+	matrices = a.Analyze(tr)
+	want = []Matrix{
+		{Base: 16, Lost: 4, Recovered: 0, Unrecoverable: 4, Potential: 0, ColumnFEC: 4, RowFEC: 4},
+		{Base: 32, Lost: 1, Recovered: 0, Unrecoverable: 1, Potential: 1, ColumnFEC: 0, RowFEC: 0},
+	}
+	if !reflect.DeepEqual(matrices, want) {
+		t.Fatalf("%s, Analyze():%+v != want:%+v", t.Name(), matrices, want)
+	}
+
+	// each matrix is only analyzed once
+	if matrices = a.Analyze(tr); matrices != nil {
+		t.Fatalf("%s, Analyze() again:%+v", t.Name(), matrices)
+	}
+
+	totals := Totals{Matrices: 3, Lost: 7, Recovered: 2, Unrecoverable: 5, Potential: 3}
+	if a.Totals() != totals {
+		t.Fatalf("%s, Totals():%+v != totals:%+v", t.Name(), a.Totals(), totals)
+	}
+	if len(a.columns) != 0 || len(a.rows) != 0 {
+		t.Fatalf("%s, FEC not pruned columns:%d rows:%d", t.Name(), len(a.columns), len(a.rows))
+	}
+}
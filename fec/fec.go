@@ -0,0 +1,129 @@
+package fec
+
+// fec analyzes SMPTE ST 2022-1 / ST 2022-5 row and column FEC
+//
+// The media packets are arranged in a matrix of L columns by D rows, in
+// sequence number order, so each row is L consecutive packets, and each
+// column is D packets, L apart.  A column FEC packet protects a column,
+// and a row FEC packet protects a row.  Each FEC packet can recover a
+// single lost packet, so a column FEC recovers one loss per column, and
+// with row FEC as well, the row and column FEC are applied in turn until
+// no more packets can be recovered.
+//
+// The column FEC is the first FEC stream, and the row FEC the second.
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+//     0                   1                   2                   3
+//     0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |      SNBase low bits          |        Length recovery        |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |E| PT recovery |                    Mask                       |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |                          TS recovery                          |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |X|D|type |index|    Offset     |       NA      |SNBase ext bits|
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/randomizedcoder/goTrackRTP/rtp"
+)
+
+const (
+	HeaderLenCst = 16
+
+	// ST 2022-1 matrix limits
+	MinLCst  = 1
+	MaxLCst  = 20
+	MinDCst  = 4
+	MaxDCst  = 20
+	MaxLDCst = 100
+
+	// MinRowLCst is the minimum L with row FEC
+	MinRowLCst = 4
+)
+
+var (
+	ErrHeaderShort = errors.New("ErrHeaderShort payload shorter than the FEC header")
+	ErrLMin        = errors.New("ErrL columns min")
+	ErrLMax        = errors.New("ErrL columns max")
+	ErrDMin        = errors.New("ErrD rows min")
+	ErrDMax        = errors.New("ErrD rows max")
+	ErrLDMax       = errors.New("ErrLD L x D max")
+	ErrMatrix      = errors.New("ErrMatrix FEC packet doesn't match the L x D matrix")
+)
+
+// ParseError describes a malformed packet
+// Use errors.Is to match the underlying Err
+type ParseError struct {
+	Err    error
+	Offset int // offset in the buffer where the problem was found
+	Len    int // length of the buffer
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("fec: %v, offset:%d, len:%d", e.Err, e.Offset, e.Len)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Header is the ST 2022-1 FEC header
+type Header struct {
+	SNBase uint16 // lowest media sequence number protected
+	Row    bool   // D bit, false for column FEC, true for row FEC
+	Type   uint8  // 0 for XOR
+	Index  uint8
+	Offset uint8 // L for column FEC, 1 for row FEC
+	NA     uint8 // D for column FEC, L for row FEC
+}
+
+// ParseHeader parses the FEC header at the start of the RTP payload
+func ParseHeader(payload []byte) (Header, error) {
+
+	if len(payload) < HeaderLenCst {
+		return Header{}, &ParseError{Err: ErrHeaderShort, Offset: 0, Len: len(payload)}
+	}
+
+	return Header{
+		SNBase: binary.BigEndian.Uint16(payload[0:2]),
+		Row:    payload[12]&0x40 != 0,
+		Type:   payload[12] >> 3 & 0x07,
+		Index:  payload[12] & 0x07,
+		Offset: payload[13],
+		NA:     payload[14],
+	}, nil
+}
+
+// ParsePacket parses the RTP header in buf, and then the FEC header
+func ParsePacket(buf []byte) (Header, error) {
+
+	var p rtp.Packet
+
+	err := p.Parse(buf)
+	if err != nil {
+		return Header{}, err
+	}
+
+	return ParseHeader(p.Payload)
+}
+
+// AppendTo appends the header, with the recovery fields zero (0)
+func (h *Header) AppendTo(b []byte) []byte {
+
+	b = binary.BigEndian.AppendUint16(b, h.SNBase)
+	b = append(b, make([]byte, 10)...)
+
+	flags := h.Type&0x07<<3 | h.Index&0x07
+	if h.Row {
+		flags |= 0x40
+	}
+
+	return append(b, flags, h.Offset, h.NA, 0)
+}
@@ -0,0 +1,55 @@
+package fec
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+
+	type test struct {
+		name string
+		h    Header
+		buf  []byte
+	}
+
+	tests := []test{
+		{"column", Header{SNBase: 0x1234, Offset: 10, NA: 5},
+			[]byte{0x12, 0x34, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x00, 10, 5, 0}},
+		{"row", Header{SNBase: 0xFFFF, Row: true, Index: 1, Offset: 1, NA: 10},
+			[]byte{0xFF, 0xFF, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x41, 1, 10, 0}},
+	}
+
+	for i, tc := range tests {
+
+		buf := tc.h.AppendTo(nil)
+		if !reflect.DeepEqual(buf, tc.buf) {
+			t.Fatalf("%s, test:%d %s buf:%x != tc.buf:%x", t.Name(), i, tc.name, buf, tc.buf)
+		}
+
+		h, err := ParseHeader(buf)
+		if err != nil {
+			t.Fatalf("%s, test:%d %s ParseHeader err:%v", t.Name(), i, tc.name, err)
+		}
+		if h != tc.h {
+			t.Fatalf("%s, test:%d %s h:%+v != tc.h:%+v", t.Name(), i, tc.name, h, tc.h)
+		}
+
+		// behind an RTP header
+		pkt := append([]byte{0x80, 0x60, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 1}, buf...)
+		h, err = ParsePacket(pkt)
+		if err != nil || h != tc.h {
+			t.Fatalf("%s, test:%d %s ParsePacket h:%+v err:%v", t.Name(), i, tc.name, h, err)
+		}
+	}
+
+	_, err := ParseHeader(make([]byte, HeaderLenCst-1))
+	if !errors.Is(err, ErrHeaderShort) {
+		t.Fatalf("%s, short err:%v != ErrHeaderShort", t.Name(), err)
+	}
+}
@@ -0,0 +1,228 @@
+package pcap
+
+// decode extracts UDP datagrams from captured packets
+//
+// Ethernet, with any number of 802.1Q/802.1ad VLAN tags, Linux cooked
+// capture (SLL), BSD loopback (Null), and raw IP link types are supported.
+// IPv4 fragments after the first are skipped, as they have no UDP header.
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+)
+
+const (
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86DD
+	etherTypeVLAN = 0x8100
+	etherTypeQinQ = 0x88A8
+
+	ethernetHeaderLen = 14
+	vlanTagLen        = 4
+	sllHeaderLen      = 16
+	nullHeaderLen     = 4
+	ipv4MinHeaderLen  = 20
+	ipv6HeaderLen     = 40
+	udpHeaderLen      = 8
+
+	// ProtoUDP is the IP protocol number for UDP
+	ProtoUDP = 17
+
+	ipv6HopByHop    = 0
+	ipv6Routing     = 43
+	ipv6Fragment    = 44
+	ipv6DestOptions = 60
+)
+
+var (
+	ErrNotUDP   = errors.New("ErrNotUDP packet is not UDP")
+	ErrShort    = errors.New("ErrShort packet is too short")
+	ErrLinkType = errors.New("ErrLinkType unsupported link type")
+	ErrFragment = errors.New("ErrFragment non-first IP fragment")
+)
+
+// UDP is a decoded UDP datagram
+// Payload references the packet data
+type UDP struct {
+	Src     netip.AddrPort
+	Dst     netip.AddrPort
+	Payload []byte
+}
+
+// DecodeUDP decodes the link, IP, and UDP headers
+func DecodeUDP(linkType uint32, data []byte) (UDP, error) {
+
+	switch linkType {
+	case LinkTypeEthernet:
+		return decodeEthernet(data)
+	case LinkTypeLinuxSLL:
+		if len(data) < sllHeaderLen {
+			return UDP{}, ErrShort
+		}
+		return decodeEtherType(binary.BigEndian.Uint16(data[14:16]), data[sllHeaderLen:])
+	case LinkTypeNull:
+		// The address family is in host byte order, so check the IP version instead
+		if len(data) < nullHeaderLen {
+			return UDP{}, ErrShort
+		}
+		return decodeIP(data[nullHeaderLen:])
+	case LinkTypeRaw:
+		return decodeIP(data)
+	}
+
+	return UDP{}, ErrLinkType
+}
+
+// decodeEthernet skips the ethernet header, and any VLAN tags
+func decodeEthernet(data []byte) (UDP, error) {
+
+	if len(data) < ethernetHeaderLen {
+		return UDP{}, ErrShort
+	}
+
+	etherType := binary.BigEndian.Uint16(data[12:14])
+	data = data[ethernetHeaderLen:]
+
+	for etherType == etherTypeVLAN || etherType == etherTypeQinQ {
+		if len(data) < vlanTagLen {
+			return UDP{}, ErrShort
+		}
+		etherType = binary.BigEndian.Uint16(data[2:4])
+		data = data[vlanTagLen:]
+	}
+
+	return decodeEtherType(etherType, data)
+}
+
+// decodeEtherType decodes IPv4 or IPv6
+func decodeEtherType(etherType uint16, data []byte) (UDP, error) {
+
+	switch etherType {
+	case etherTypeIPv4:
+		return decodeIPv4(data)
+	case etherTypeIPv6:
+		return decodeIPv6(data)
+	}
+
+	return UDP{}, ErrNotUDP
+}
+
+// decodeIP decodes IPv4 or IPv6 using the version nibble
+func decodeIP(data []byte) (UDP, error) {
+
+	if len(data) < 1 {
+		return UDP{}, ErrShort
+	}
+
+	switch data[0] >> 4 {
+	case 4:
+		return decodeIPv4(data)
+	case 6:
+		return decodeIPv6(data)
+	}
+
+	return UDP{}, ErrNotUDP
+}
+
+// decodeIPv4 decodes the IPv4 header
+func decodeIPv4(data []byte) (UDP, error) {
+
+	if len(data) < ipv4MinHeaderLen {
+		return UDP{}, ErrShort
+	}
+
+	ihl := int(data[0]&0x0F) * 4
+	if ihl < ipv4MinHeaderLen || len(data) < ihl {
+		return UDP{}, ErrShort
+	}
+
+	if data[9] != ProtoUDP {
+		return UDP{}, ErrNotUDP
+	}
+
+	// fragment offset is the low 13 bits
+	if binary.BigEndian.Uint16(data[6:8])&0x1FFF != 0 {
+		return UDP{}, ErrFragment
+	}
+
+	// trim any ethernet padding using the total length
+	totalLen := int(binary.BigEndian.Uint16(data[2:4]))
+	if totalLen >= ihl && totalLen < len(data) {
+		data = data[:totalLen]
+	}
+
+	src := netip.AddrFrom4([4]byte(data[12:16]))
+	dst := netip.AddrFrom4([4]byte(data[16:20]))
+
+	return decodeUDP(src, dst, data[ihl:])
+}
+
+// decodeIPv6 decodes the IPv6 header, skipping the common extension headers
+func decodeIPv6(data []byte) (UDP, error) {
+
+	if len(data) < ipv6HeaderLen {
+		return UDP{}, ErrShort
+	}
+
+	payloadLen := int(binary.BigEndian.Uint16(data[4:6]))
+	next := data[6]
+
+	src := netip.AddrFrom16([16]byte(data[8:24]))
+	dst := netip.AddrFrom16([16]byte(data[24:40]))
+
+	data = data[ipv6HeaderLen:]
+	if payloadLen < len(data) {
+		data = data[:payloadLen]
+	}
+
+	for {
+		switch next {
+		case ProtoUDP:
+			return decodeUDP(src, dst, data)
+		case ipv6HopByHop, ipv6Routing, ipv6DestOptions:
+			if len(data) < 8 {
+				return UDP{}, ErrShort
+			}
+			l := (int(data[1]) + 1) * 8
+			if len(data) < l {
+				return UDP{}, ErrShort
+			}
+			next = data[0]
+			data = data[l:]
+		case ipv6Fragment:
+			if len(data) < 8 {
+				return UDP{}, ErrShort
+			}
+			if binary.BigEndian.Uint16(data[2:4])&0xFFF8 != 0 {
+				return UDP{}, ErrFragment
+			}
+			next = data[0]
+			data = data[8:]
+		default:
+			return UDP{}, ErrNotUDP
+		}
+	}
+}
+
+// decodeUDP decodes the UDP header
+func decodeUDP(src, dst netip.Addr, data []byte) (UDP, error) {
+
+	if len(data) < udpHeaderLen {
+		return UDP{}, ErrShort
+	}
+
+	// trim to the UDP length, which may be less than the captured length
+	l := int(binary.BigEndian.Uint16(data[4:6]))
+	if l >= udpHeaderLen && l < len(data) {
+		data = data[:l]
+	}
+
+	return UDP{
+		Src:     netip.AddrPortFrom(src, binary.BigEndian.Uint16(data[0:2])),
+		Dst:     netip.AddrPortFrom(dst, binary.BigEndian.Uint16(data[2:4])),
+		Payload: data[udpHeaderLen:],
+	}, nil
+}
@@ -0,0 +1,122 @@
+package pcap
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+// udp builds a UDP header and payload
+func udp(src, dst uint16, payload []byte) []byte {
+	b := make([]byte, udpHeaderLen, udpHeaderLen+len(payload))
+	binary.BigEndian.PutUint16(b[0:2], src)
+	binary.BigEndian.PutUint16(b[2:4], dst)
+	binary.BigEndian.PutUint16(b[4:6], uint16(udpHeaderLen+len(payload)))
+	return append(b, payload...)
+}
+
+// ipv4 builds an IPv4 header
+func ipv4(proto uint8, fragOffset uint16, src, dst [4]byte, payload []byte) []byte {
+	b := make([]byte, ipv4MinHeaderLen, ipv4MinHeaderLen+len(payload))
+	b[0] = 0x45
+	binary.BigEndian.PutUint16(b[2:4], uint16(ipv4MinHeaderLen+len(payload)))
+	binary.BigEndian.PutUint16(b[6:8], fragOffset)
+	b[8] = 64
+	b[9] = proto
+	copy(b[12:16], src[:])
+	copy(b[16:20], dst[:])
+	return append(b, payload...)
+}
+
+// ipv6 builds an IPv6 header
+func ipv6(next uint8, src, dst [16]byte, payload []byte) []byte {
+	b := make([]byte, ipv6HeaderLen, ipv6HeaderLen+len(payload))
+	b[0] = 0x60
+	binary.BigEndian.PutUint16(b[4:6], uint16(len(payload)))
+	b[6] = next
+	b[7] = 64
+	copy(b[8:24], src[:])
+	copy(b[24:40], dst[:])
+	return append(b, payload...)
+}
+
+// ethernet builds an ethernet header, with optional VLAN tags
+func ethernet(etherType uint16, vlans []uint16, payload []byte) []byte {
+	b := make([]byte, 12)
+	for _, v := range vlans {
+		b = binary.BigEndian.AppendUint16(b, etherTypeVLAN)
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+	b = binary.BigEndian.AppendUint16(b, etherType)
+	return append(b, payload...)
+}
+
+func TestDecodeUDP(t *testing.T) {
+
+	src4 := [4]byte{10, 0, 0, 1}
+	dst4 := [4]byte{239, 1, 1, 1}
+	src6 := netip.MustParseAddr("2001:db8::1").As16()
+	dst6 := netip.MustParseAddr("ff02::1").As16()
+
+	payload := []byte{0x80, 0x21, 0x00, 0x01}
+	u := udp(5000, 6000, payload)
+	v4 := ipv4(ProtoUDP, 0, src4, dst4, u)
+	v6 := ipv6(ProtoUDP, src6, dst6, u)
+
+	// hop by hop extension header, then UDP
+	hbh := append([]byte{ProtoUDP, 0, 0, 0, 0, 0, 0, 0}, u...)
+
+	sll := make([]byte, sllHeaderLen)
+	binary.BigEndian.PutUint16(sll[14:16], etherTypeIPv4)
+
+	want4 := UDP{netip.AddrPortFrom(netip.AddrFrom4(src4), 5000), netip.AddrPortFrom(netip.AddrFrom4(dst4), 6000), payload}
+	want6 := UDP{netip.AddrPortFrom(netip.AddrFrom16(src6), 5000), netip.AddrPortFrom(netip.AddrFrom16(dst6), 6000), payload}
+
+	type test struct {
+		name     string
+		linkType uint32
+		data     []byte
+		udp      UDP
+		err      error
+	}
+
+	tests := []test{
+		{"ethernet ipv4", LinkTypeEthernet, ethernet(etherTypeIPv4, nil, v4), want4, nil},
+		{"ethernet ipv6", LinkTypeEthernet, ethernet(etherTypeIPv6, nil, v6), want6, nil},
+		{"vlan", LinkTypeEthernet, ethernet(etherTypeIPv4, []uint16{100}, v4), want4, nil},
+		{"qinq", LinkTypeEthernet, ethernet(etherTypeIPv4, []uint16{100, 200}, v4), want4, nil},
+		{"ethernet padding", LinkTypeEthernet, append(ethernet(etherTypeIPv4, nil, v4), 0, 0, 0, 0), want4, nil},
+		{"raw ipv4", LinkTypeRaw, v4, want4, nil},
+		{"raw ipv6", LinkTypeRaw, v6, want6, nil},
+		{"null", LinkTypeNull, append([]byte{2, 0, 0, 0}, v4...), want4, nil},
+		{"sll", LinkTypeLinuxSLL, append(sll, v4...), want4, nil},
+		{"ipv6 extension", LinkTypeRaw, ipv6(ipv6HopByHop, src6, dst6, hbh), want6, nil},
+		// errors
+		{"tcp", LinkTypeRaw, ipv4(6, 0, src4, dst4, u), UDP{}, ErrNotUDP},
+		{"arp", LinkTypeEthernet, ethernet(0x0806, nil, make([]byte, 28)), UDP{}, ErrNotUDP},
+		{"fragment", LinkTypeRaw, ipv4(ProtoUDP, 100, src4, dst4, u), UDP{}, ErrFragment},
+		{"short ethernet", LinkTypeEthernet, make([]byte, 10), UDP{}, ErrShort},
+		{"short udp", LinkTypeRaw, ipv4(ProtoUDP, 0, src4, dst4, u[:4]), UDP{}, ErrShort},
+		{"link type", 999, v4, UDP{}, ErrLinkType},
+	}
+
+	for i, tc := range tests {
+
+		got, err := DecodeUDP(tc.linkType, tc.data)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.udp) {
+			t.Fatalf("%s, test:%d %s got:%v != tc.udp:%v", t.Name(), i, tc.name, got, tc.udp)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package pcap
+
+// pcap is a pure Go reader for classic pcap and pcapng capture files
+//
+// There is no libpcap, or cgo, dependency.
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// https://www.ietf.org/archive/id/draft-ietf-opsawg-pcap-04.html
+// https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-01.html
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+	"time"
+)
+
+const (
+	// classic pcap magic numbers
+	magicMicroseconds = 0xA1B2C3D4
+	magicNanoseconds  = 0xA1B23C4D
+
+	// pcapng block types
+	blockTypeSHB = 0x0A0D0D0A // Section Header Block
+	blockTypeIDB = 0x00000001 // Interface Description Block
+	blockTypeSPB = 0x00000003 // Simple Packet Block
+	blockTypeEPB = 0x00000006 // Enhanced Packet Block
+
+	byteOrderMagic = 0x1A2B3C4D
+
+	optionEndOfOpt = 0
+	optionTsResol  = 9
+
+	classicHeaderLen       = 24
+	classicRecordHeaderLen = 16
+	blockHeaderLen         = 8
+
+	// MaxPacketLenCst limits the packet buffer, so a corrupt capture can't
+	// cause a huge allocation
+	MaxPacketLenCst = 256 * 1024
+)
+
+// Link types
+// https://www.tcpdump.org/linktypes.html
+const (
+	LinkTypeNull     = 0
+	LinkTypeEthernet = 1
+	LinkTypeRaw      = 101
+	LinkTypeLinuxSLL = 113
+)
+
+var (
+	ErrMagic       = errors.New("ErrMagic not a pcap or pcapng file")
+	ErrBlockLength = errors.New("ErrBlockLength invalid pcapng block length")
+	ErrPacketLen   = errors.New("ErrPacketLen packet length larger than MaxPacketLenCst")
+	ErrInterface   = errors.New("ErrInterface pcapng packet for unknown interface")
+)
+
+// Packet is a single captured packet
+// Data is only valid until the next call to Next()
+type Packet struct {
+	Timestamp time.Time
+	LinkType  uint32
+	Data      []byte
+	OrigLen   int // length of the packet on the wire, which may be more than len(Data)
+}
+
+// iface is a pcapng interface
+type iface struct {
+	linkType uint32
+	snapLen  uint32
+	tsPerSec uint64 // timestamp units per second, from if_tsresol
+}
+
+// Reader reads packets from a pcap or pcapng capture
+type Reader struct {
+	r   *bufio.Reader
+	buf []byte
+
+	ng bool
+
+	// classic
+	order    binary.ByteOrder
+	linkType uint32
+	nanos    bool
+
+	// pcapng
+	ifaces []iface
+}
+
+// NewReader reads the file header, detecting pcap or pcapng
+func NewReader(r io.Reader) (*Reader, error) {
+
+	pr := &Reader{
+		r: bufio.NewReader(r),
+	}
+
+	magic, err := pr.r.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+
+	if binary.BigEndian.Uint32(magic) == blockTypeSHB {
+		pr.ng = true
+		return pr, nil
+	}
+
+	err = pr.readClassicHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// IsNG returns true for pcapng captures
+func (pr *Reader) IsNG() bool {
+	return pr.ng
+}
+
+// readClassicHeader reads the classic pcap file header
+func (pr *Reader) readClassicHeader() error {
+
+	h := make([]byte, classicHeaderLen)
+	_, err := io.ReadFull(pr.r, h)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(h[0:4]) == magicMicroseconds:
+		pr.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(h[0:4]) == magicMicroseconds:
+		pr.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(h[0:4]) == magicNanoseconds:
+		pr.order = binary.LittleEndian
+		pr.nanos = true
+	case binary.BigEndian.Uint32(h[0:4]) == magicNanoseconds:
+		pr.order = binary.BigEndian
+		pr.nanos = true
+	default:
+		return ErrMagic
+	}
+
+	// The link type is the low 16 bits, the upper bits are the FCS flags
+	pr.linkType = pr.order.Uint32(h[20:24]) & 0xFFFF
+
+	return nil
+}
+
+// Next returns the next packet, or io.EOF at the end of the capture
+func (pr *Reader) Next() (Packet, error) {
+	if pr.ng {
+		return pr.nextNG()
+	}
+	return pr.nextClassic()
+}
+
+// read reads n bytes into the reused buffer
+func (pr *Reader) read(n int) ([]byte, error) {
+
+	if n > MaxPacketLenCst {
+		return nil, ErrPacketLen
+	}
+	if cap(pr.buf) < n {
+		pr.buf = make([]byte, n)
+	}
+	b := pr.buf[:n]
+
+	_, err := io.ReadFull(pr.r, b)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// nextClassic reads a classic pcap record
+func (pr *Reader) nextClassic() (Packet, error) {
+
+	var h [classicRecordHeaderLen]byte
+	_, err := io.ReadFull(pr.r, h[:])
+	if err != nil {
+		return Packet{}, err
+	}
+
+	sec := int64(pr.order.Uint32(h[0:4]))
+	frac := int64(pr.order.Uint32(h[4:8]))
+	capLen := int(pr.order.Uint32(h[8:12]))
+	origLen := int(pr.order.Uint32(h[12:16]))
+
+	if !pr.nanos {
+		frac *= int64(time.Microsecond)
+	}
+
+	data, err := pr.read(capLen)
+	if err != nil {
+		return Packet{}, err
+	}
+
+	return Packet{
+		Timestamp: time.Unix(sec, frac),
+		LinkType:  pr.linkType,
+		Data:      data,
+		OrigLen:   origLen,
+	}, nil
+}
+
+// nextNG reads pcapng blocks until a packet block is found
+func (pr *Reader) nextNG() (Packet, error) {
+
+	for {
+		var h [blockHeaderLen]byte
+		_, err := io.ReadFull(pr.r, h[:])
+		if err != nil {
+			return Packet{}, err
+		}
+
+		// The section header block type is a palindrome, so the byte order
+		// is found from the byte order magic which follows
+		if binary.BigEndian.Uint32(h[0:4]) == blockTypeSHB {
+			err = pr.readSHB(h)
+			if err != nil {
+				return Packet{}, err
+			}
+			continue
+		}
+
+		if pr.order == nil {
+			return Packet{}, ErrMagic
+		}
+
+		blockType := pr.order.Uint32(h[0:4])
+		blockLen := int(pr.order.Uint32(h[4:8]))
+		if blockLen < blockHeaderLen+4 || blockLen%4 != 0 {
+			return Packet{}, ErrBlockLength
+		}
+
+		// body, including the trailing block length
+		body, err := pr.read(blockLen - blockHeaderLen)
+		if err != nil {
+			return Packet{}, err
+		}
+		body = body[:len(body)-4]
+
+		switch blockType {
+		case blockTypeIDB:
+			err = pr.readIDB(body)
+			if err != nil {
+				return Packet{}, err
+			}
+		case blockTypeEPB:
+			return pr.readEPB(body)
+		case blockTypeSPB:
+			return pr.readSPB(body)
+		}
+		// other blocks are skipped
+	}
+}
+
+// readSHB reads the section header block, which resets the interfaces
+func (pr *Reader) readSHB(h [blockHeaderLen]byte) error {
+
+	bom, err := pr.r.Peek(4)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(bom) == byteOrderMagic:
+		pr.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(bom) == byteOrderMagic:
+		pr.order = binary.BigEndian
+	default:
+		return ErrMagic
+	}
+
+	blockLen := int(pr.order.Uint32(h[4:8]))
+	if blockLen < blockHeaderLen+4 || blockLen%4 != 0 {
+		return ErrBlockLength
+	}
+
+	_, err = pr.read(blockLen - blockHeaderLen)
+	if err != nil {
+		return err
+	}
+
+	pr.ifaces = pr.ifaces[:0]
+
+	return nil
+}
+
+// readIDB reads the interface description block
+func (pr *Reader) readIDB(body []byte) error {
+
+	if len(body) < 8 {
+		return ErrBlockLength
+	}
+
+	ifc := iface{
+		linkType: uint32(pr.order.Uint16(body[0:2])),
+		snapLen:  pr.order.Uint32(body[4:8]),
+		tsPerSec: 1e6, // microseconds by default
+	}
+
+	// options
+	opts := body[8:]
+	for len(opts) >= 4 {
+		code := pr.order.Uint16(opts[0:2])
+		l := int(pr.order.Uint16(opts[2:4]))
+		if code == optionEndOfOpt {
+			break
+		}
+		padded := (l + 3) &^ 3
+		if len(opts) < 4+padded {
+			return ErrBlockLength
+		}
+		if code == optionTsResol && l >= 1 {
+			ifc.tsPerSec = tsResol(opts[4])
+		}
+		opts = opts[4+padded:]
+	}
+
+	pr.ifaces = append(pr.ifaces, ifc)
+
+	return nil
+}
+
+// tsResol returns the units per second for the if_tsresol option
+// The most significant bit selects a power of two, otherwise power of ten
+func tsResol(v uint8) uint64 {
+
+	base := uint64(10)
+	if v&0x80 != 0 {
+		base = 2
+		v &= 0x7F
+	}
+
+	perSec := uint64(1)
+	for i := uint8(0); i < v; i++ {
+		next := perSec * base
+		if next/base != perSec {
+			// overflow, so truncate to the finest representable
+			break
+		}
+		perSec = next
+	}
+
+	return perSec
+}
+
+// timestamp converts the pcapng 64 bit timestamp using the interface resolution
+func (ifc *iface) timestamp(ts uint64) time.Time {
+
+	sec := ts / ifc.tsPerSec
+	frac := ts % ifc.tsPerSec
+
+	// frac < tsPerSec, so the quotient fits, and Div64 won't panic
+	hi, lo := bits.Mul64(frac, uint64(time.Second))
+	nanos, _ := bits.Div64(hi, lo, ifc.tsPerSec)
+
+	return time.Unix(int64(sec), int64(nanos))
+}
+
+// readEPB reads the enhanced packet block
+func (pr *Reader) readEPB(body []byte) (Packet, error) {
+
+	if len(body) < 20 {
+		return Packet{}, ErrBlockLength
+	}
+
+	id := int(pr.order.Uint32(body[0:4]))
+	if id >= len(pr.ifaces) {
+		return Packet{}, ErrInterface
+	}
+	ifc := &pr.ifaces[id]
+
+	ts := uint64(pr.order.Uint32(body[4:8]))<<32 | uint64(pr.order.Uint32(body[8:12]))
+	capLen := int(pr.order.Uint32(body[12:16]))
+	origLen := int(pr.order.Uint32(body[16:20]))
+
+	if len(body) < 20+capLen {
+		return Packet{}, ErrBlockLength
+	}
+
+	return Packet{
+		Timestamp: ifc.timestamp(ts),
+		LinkType:  ifc.linkType,
+		Data:      body[20 : 20+capLen],
+		OrigLen:   origLen,
+	}, nil
+}
+
+// readSPB reads the simple packet block, which has no timestamp, and is
+// always for the first interface
+func (pr *Reader) readSPB(body []byte) (Packet, error) {
+
+	if len(body) < 4 {
+		return Packet{}, ErrBlockLength
+	}
+	if len(pr.ifaces) == 0 {
+		return Packet{}, ErrInterface
+	}
+	ifc := &pr.ifaces[0]
+
+	origLen := int(pr.order.Uint32(body[0:4]))
+	capLen := origLen
+	if ifc.snapLen > 0 && capLen > int(ifc.snapLen) {
+		capLen = int(ifc.snapLen)
+	}
+	if capLen > len(body)-4 {
+		capLen = len(body) - 4
+	}
+
+	return Packet{
+		LinkType: ifc.linkType,
+		Data:     body[4 : 4+capLen],
+		OrigLen:  origLen,
+	}, nil
+}
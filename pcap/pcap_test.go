@@ -0,0 +1,282 @@
+package pcap
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// classic builds a classic pcap capture
+func classic(order binary.ByteOrder, magic uint32, linkType uint32, ts []time.Time, pkts [][]byte) []byte {
+
+	var b bytes.Buffer
+	h := make([]byte, classicHeaderLen)
+	order.PutUint32(h[0:4], magic)
+	order.PutUint16(h[4:6], 2)
+	order.PutUint16(h[6:8], 4)
+	order.PutUint32(h[16:20], 65535)
+	order.PutUint32(h[20:24], linkType)
+	b.Write(h)
+
+	for i, p := range pkts {
+		r := make([]byte, classicRecordHeaderLen)
+		order.PutUint32(r[0:4], uint32(ts[i].Unix()))
+		frac := ts[i].Nanosecond()
+		if magic == magicMicroseconds {
+			frac /= int(time.Microsecond)
+		}
+		order.PutUint32(r[4:8], uint32(frac))
+		order.PutUint32(r[8:12], uint32(len(p)))
+		order.PutUint32(r[12:16], uint32(len(p)))
+		b.Write(r)
+		b.Write(p)
+	}
+
+	return b.Bytes()
+}
+
+// block builds a pcapng block, padding the body
+func block(order binary.ByteOrder, blockType uint32, body []byte) []byte {
+
+	padded := (len(body) + 3) &^ 3
+	l := blockHeaderLen + padded + 4
+
+	b := make([]byte, l)
+	order.PutUint32(b[0:4], blockType)
+	order.PutUint32(b[4:8], uint32(l))
+	copy(b[8:], body)
+	order.PutUint32(b[l-4:], uint32(l))
+
+	return b
+}
+
+// ng builds a pcapng capture with a single interface
+// tsresol of zero (0) leaves out the option, so the default microseconds are used
+func ng(order binary.ByteOrder, linkType uint16, tsresol uint8, ticks []uint64, pkts [][]byte) []byte {
+
+	var b bytes.Buffer
+
+	shb := make([]byte, 16)
+	order.PutUint32(shb[0:4], byteOrderMagic)
+	order.PutUint16(shb[4:6], 1)
+	binary.LittleEndian.PutUint64(shb[8:16], 0xFFFFFFFFFFFFFFFF) // section length unknown
+	b.Write(block(order, blockTypeSHB, shb))
+
+	idb := make([]byte, 8)
+	order.PutUint16(idb[0:2], linkType)
+	order.PutUint32(idb[4:8], 65535)
+	if tsresol != 0 {
+		opt := make([]byte, 12)
+		order.PutUint16(opt[0:2], optionTsResol)
+		order.PutUint16(opt[2:4], 1)
+		opt[4] = tsresol
+		// opt[8:12] is opt_endofopt
+		idb = append(idb, opt...)
+	}
+	b.Write(block(order, blockTypeIDB, idb))
+
+	// an unknown block, which should be skipped
+	b.Write(block(order, 0x00000BAD, []byte{1, 2, 3}))
+
+	for i, p := range pkts {
+		epb := make([]byte, 20)
+		order.PutUint32(epb[4:8], uint32(ticks[i]>>32))
+		order.PutUint32(epb[8:12], uint32(ticks[i]))
+		order.PutUint32(epb[12:16], uint32(len(p)))
+		order.PutUint32(epb[16:20], uint32(len(p)))
+		epb = append(epb, p...)
+		b.Write(block(order, blockTypeEPB, epb))
+	}
+
+	return b.Bytes()
+}
+
+// readAll reads all the packets, copying the data
+func readAll(t *testing.T, buf []byte) ([]Packet, bool) {
+
+	r, err := NewReader(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("%s, NewReader err:%v", t.Name(), err)
+	}
+
+	var pkts []Packet
+	for {
+		p, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("%s, Next err:%v", t.Name(), err)
+		}
+		p.Data = append([]byte(nil), p.Data...)
+		pkts = append(pkts, p)
+	}
+
+	return pkts, r.IsNG()
+}
+
+func TestReaderClassic(t *testing.T) {
+
+	ts := []time.Time{time.Unix(1700000000, 123456000), time.Unix(1700000001, 999999000)}
+	tsNanos := []time.Time{time.Unix(1700000000, 123456789), time.Unix(1700000001, 1)}
+	data := [][]byte{{1, 2, 3}, {4, 5, 6, 7, 8}}
+
+	type test struct {
+		name string
+		buf  []byte
+		ts   []time.Time
+	}
+
+	tests := []test{
+		{"little micro", classic(binary.LittleEndian, magicMicroseconds, LinkTypeEthernet, ts, data), ts},
+		{"big micro", classic(binary.BigEndian, magicMicroseconds, LinkTypeEthernet, ts, data), ts},
+		{"little nano", classic(binary.LittleEndian, magicNanoseconds, LinkTypeEthernet, tsNanos, data), tsNanos},
+		{"big nano", classic(binary.BigEndian, magicNanoseconds, LinkTypeEthernet, tsNanos, data), tsNanos},
+	}
+
+	for i, tc := range tests {
+
+		pkts, isNG := readAll(t, tc.buf)
+		if isNG {
+			t.Fatalf("%s, test:%d %s IsNG() != false", t.Name(), i, tc.name)
+		}
+		if len(pkts) != len(data) {
+			t.Fatalf("%s, test:%d %s len(pkts):%d != %d", t.Name(), i, tc.name, len(pkts), len(data))
+		}
+		for j, p := range pkts {
+			if !p.Timestamp.Equal(tc.ts[j]) {
+				t.Fatalf("%s, test:%d %s p.Timestamp:%v != %v", t.Name(), i, tc.name, p.Timestamp, tc.ts[j])
+			}
+			if !reflect.DeepEqual(p.Data, data[j]) {
+				t.Fatalf("%s, test:%d %s p.Data:%v != %v", t.Name(), i, tc.name, p.Data, data[j])
+			}
+			if p.LinkType != LinkTypeEthernet || p.OrigLen != len(data[j]) {
+				t.Fatalf("%s, test:%d %s p.LinkType:%d p.OrigLen:%d", t.Name(), i, tc.name, p.LinkType, p.OrigLen)
+			}
+		}
+	}
+}
+
+func TestReaderNG(t *testing.T) {
+
+	data := [][]byte{{1, 2, 3}, {4, 5, 6, 7, 8}}
+
+	type test struct {
+		name    string
+		order   binary.ByteOrder
+		tsresol uint8
+		ticks   []uint64
+		ts      []time.Time
+	}
+
+	tests := []test{
+		{"default micro", binary.LittleEndian, 0,
+			[]uint64{1700000000123456, 1700000001000001},
+			[]time.Time{time.Unix(1700000000, 123456000), time.Unix(1700000001, 1000)}},
+		{"big endian nano", binary.BigEndian, 9,
+			[]uint64{1700000000123456789, 1700000001000000001},
+			[]time.Time{time.Unix(1700000000, 123456789), time.Unix(1700000001, 1)}},
+		{"milli", binary.LittleEndian, 3,
+			[]uint64{1700000000123, 1700000001999},
+			[]time.Time{time.Unix(1700000000, 123000000), time.Unix(1700000001, 999000000)}},
+		{"pow2", binary.LittleEndian, 0x80 | 10,
+			[]uint64{1024*1700000000 + 512, 1024*1700000001 + 1},
+			[]time.Time{time.Unix(1700000000, 500000000), time.Unix(1700000001, 976562)}},
+	}
+
+	for i, tc := range tests {
+
+		pkts, isNG := readAll(t, ng(tc.order, LinkTypeRaw, tc.tsresol, tc.ticks, data))
+		if !isNG {
+			t.Fatalf("%s, test:%d %s IsNG() != true", t.Name(), i, tc.name)
+		}
+		if len(pkts) != len(data) {
+			t.Fatalf("%s, test:%d %s len(pkts):%d != %d", t.Name(), i, tc.name, len(pkts), len(data))
+		}
+		for j, p := range pkts {
+			if !p.Timestamp.Equal(tc.ts[j]) {
+				t.Fatalf("%s, test:%d %s p.Timestamp:%v != %v", t.Name(), i, tc.name, p.Timestamp, tc.ts[j])
+			}
+			if !reflect.DeepEqual(p.Data, data[j]) {
+				t.Fatalf("%s, test:%d %s p.Data:%v != %v", t.Name(), i, tc.name, p.Data, data[j])
+			}
+			if p.LinkType != LinkTypeRaw {
+				t.Fatalf("%s, test:%d %s p.LinkType:%d", t.Name(), i, tc.name, p.LinkType)
+			}
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+
+	ts := []time.Time{time.Unix(1, 0)}
+	data := [][]byte{{1, 2, 3, 4}}
+
+	good := classic(binary.LittleEndian, magicMicroseconds, LinkTypeEthernet, ts, data)
+	goodNG := ng(binary.LittleEndian, LinkTypeEthernet, 0, []uint64{1}, data)
+
+	// The last block is the EPB, with a 4 byte packet, so 36 bytes long.
+	// Changing the interface id to 1, which doesn't exist
+	badIface := append([]byte(nil), goodNG...)
+	binary.LittleEndian.PutUint32(badIface[len(badIface)-36+blockHeaderLen:], 1)
+
+	type test struct {
+		name string
+		buf  []byte
+		err  error
+	}
+
+	tests := []test{
+		{"magic", []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}, ErrMagic},
+		{"truncated", good[:len(good)-1], io.ErrUnexpectedEOF},
+		{"truncated ng", goodNG[:len(goodNG)-1], io.ErrUnexpectedEOF},
+		{"interface", badIface, ErrInterface},
+	}
+
+	for i, tc := range tests {
+
+		r, err := NewReader(bytes.NewReader(tc.buf))
+		if err == nil {
+			for err == nil {
+				_, err = r.Next()
+			}
+		}
+
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestTsResol(t *testing.T) {
+
+	type test struct {
+		v      uint8
+		perSec uint64
+	}
+
+	tests := []test{
+		{0, 1},
+		{3, 1e3},
+		{6, 1e6},
+		{9, 1e9},
+		{12, 1e12},
+		{0x80, 1},
+		{0x80 | 10, 1024},
+		{0x80 | 70, 1 << 63}, // overflow truncates
+	}
+
+	for i, tc := range tests {
+		if perSec := tsResol(tc.v); perSec != tc.perSec {
+			t.Fatalf("%s, test:%d tsResol(%d):%d != tc.perSec:%d", t.Name(), i, tc.v, perSec, tc.perSec)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package promexporter
+
+// promexporter exposes goTrackRTP Tracker statistics as Prometheus metrics
+//
+// The Collector reads the statistics at scrape time, so the packet
+// handling path doesn't touch Prometheus at all.  The sources are read
+// from the scrape goroutine, so they must be safe for concurrent use,
+// e.g. goTrackRTP.SafeTracker.
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// https://pkg.go.dev/github.com/prometheus/client_golang/prometheus#Collector
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+const (
+	NamespaceCst = "gotrackrtp"
+)
+
+// Source provides the statistics for a stream
+// goTrackRTP.SafeTracker implements Source
+type Source interface {
+	Stats() goTrackRTP.Stats
+	Len() int
+	Window() uint16
+}
+
+// JitterSource optionally provides the interarrival jitter
+// goTrackRTP.SafeTracker implements JitterSource, and the jitter is only
+// exported once packets have arrived with PacketArrivalTimestamp
+type JitterSource interface {
+	Jitter() goTrackRTP.Jitter
+}
+
+// BurstSource optionally provides the loss burst and gap counters
+// goTrackRTP.SafeTracker implements BurstSource
+type BurstSource interface {
+	Bursts() goTrackRTP.Bursts
+}
+
+// Stream identifies a stream, and is exported as the "ssrc" and "stream" labels
+type Stream struct {
+	SSRC uint32
+	Name string
+}
+
+// Opts configures the Collector
+type Opts struct {
+	Namespace   string // defaults to NamespaceCst
+	Subsystem   string
+	ConstLabels prometheus.Labels
+}
+
+// Collector is a prometheus.Collector over one or many streams
+type Collector struct {
+	mu      sync.Mutex
+	sources map[Stream]Source
+
+	maps *goTrackRTP.TrackIntToStringMap
+
+	packets   *prometheus.Desc
+	taxonomy  *prometheus.Desc
+	jumpTotal *prometheus.Desc
+	lost      *prometheus.Desc
+	len       *prometheus.Desc
+	window    *prometheus.Desc
+	jump      *prometheus.Desc
+	jitter    *prometheus.Desc
+	burst     *prometheus.Desc
+	gap       *prometheus.Desc
+	ge        *prometheus.Desc
+
+	jumpBuckets []float64
+}
+
+// New creates a Collector, which should then be registered
+// e.g. prometheus.MustRegister(c)
+func New(opts Opts) *Collector {
+
+	if opts.Namespace == "" {
+		opts.Namespace = NamespaceCst
+	}
+
+	streamLabels := []string{"ssrc", "stream"}
+	taxonomyLabels := []string{"ssrc", "stream", "position", "category", "subcategory"}
+	geLabels := []string{"ssrc", "stream", "counter"}
+
+	desc := func(name, help string, labels []string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(opts.Namespace, opts.Subsystem, name),
+			help, labels, opts.ConstLabels)
+	}
+
+	jumpBuckets := make([]float64, len(goTrackRTP.JumpBuckets))
+	for i, b := range goTrackRTP.JumpBuckets {
+		jumpBuckets[i] = float64(b)
+	}
+
+	return &Collector{
+		sources: make(map[Stream]Source),
+		maps:    goTrackRTP.NewMaps(),
+
+		packets:   desc("packets_total", "Packets received", streamLabels),
+		taxonomy:  desc("taxonomy_packets_total", "Packets received by position, category, and subcategory", taxonomyLabels),
+		jumpTotal: desc("jump_packets_total", "Sum of the sequence number jumps", streamLabels),
+		lost:      desc("lost_packets_total", "Sequence numbers which fell off the back of the window without being received", streamLabels),
+		len:       desc("window_len", "Packets received within the acceptable window", streamLabels),
+		window:    desc("window_size", "Acceptable window size ( aw + bw )", streamLabels),
+		jump:      desc("jump", "Histogram of the non-zero sequence number jumps", streamLabels),
+		jitter:    desc("jitter_seconds", "RFC 3550 interarrival jitter", streamLabels),
+		burst:     desc("loss_burst_length", "Histogram of the consecutive lost sequence numbers", streamLabels),
+		gap:       desc("gap_length", "Histogram of the consecutive received sequence numbers", streamLabels),
+		ge:        desc("gilbert_elliott_total", "Gilbert-Elliott state counters, to fit the model ( p, r, 1-k, h ) using rate()", geLabels),
+
+		jumpBuckets: jumpBuckets,
+	}
+}
+
+// Add starts exporting the stream, replacing any existing source for the stream
+func (c *Collector) Add(stream Stream, src Source) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sources[stream] = src
+}
+
+// Remove stops exporting the stream
+func (c *Collector) Remove(stream Stream) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.sources, stream)
+}
+
+// Len returns the number of streams being exported
+func (c *Collector) Len() int {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.sources)
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.packets
+	ch <- c.taxonomy
+	ch <- c.jumpTotal
+	ch <- c.lost
+	ch <- c.len
+	ch <- c.window
+	ch <- c.jump
+	ch <- c.jitter
+	ch <- c.burst
+	ch <- c.gap
+	ch <- c.ge
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for stream, src := range c.sources {
+		c.collect(ch, stream, src)
+	}
+}
+
+// collect sends the metrics for a single stream
+func (c *Collector) collect(ch chan<- prometheus.Metric, stream Stream, src Source) {
+
+	ssrc := strconv.FormatUint(uint64(stream.SSRC), 10)
+	s := src.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.packets, prometheus.CounterValue, float64(s.Packets), ssrc, stream.Name)
+
+	// Only the combinations which have happened are exported, to limit the cardinality
+	for p := 0; p < goTrackRTP.PositionCount; p++ {
+		for ca := 0; ca < goTrackRTP.CategoryCount; ca++ {
+			for sc := 0; sc < goTrackRTP.SubCategoryCount; sc++ {
+				count := s.Counts[p][ca][sc]
+				if count == 0 {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(c.taxonomy, prometheus.CounterValue, float64(count),
+					ssrc, stream.Name, c.maps.PosMap[p], c.maps.CatMap[ca], c.maps.SubCatMap[sc])
+			}
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.jumpTotal, prometheus.CounterValue, float64(s.JumpTotal), ssrc, stream.Name)
+	ch <- prometheus.MustNewConstMetric(c.lost, prometheus.CounterValue, float64(s.Lost), ssrc, stream.Name)
+	ch <- prometheus.MustNewConstMetric(c.len, prometheus.GaugeValue, float64(src.Len()), ssrc, stream.Name)
+	ch <- prometheus.MustNewConstMetric(c.window, prometheus.GaugeValue, float64(src.Window()), ssrc, stream.Name)
+
+	ch <- c.histogram(c.jump, s.JumpHistogram, s.JumpTotal, ssrc, stream.Name)
+
+	if js, ok := src.(JitterSource); ok {
+		j := js.Jitter()
+		if j.Packets > 0 {
+			ch <- prometheus.MustNewConstMetric(c.jitter, prometheus.GaugeValue, j.JitterDuration().Seconds(), ssrc, stream.Name)
+		}
+	}
+
+	if bs, ok := src.(BurstSource); ok {
+		b := bs.Bursts()
+		ch <- c.histogram(c.burst, b.BurstHistogram, b.BurstTotal, ssrc, stream.Name)
+		ch <- c.histogram(c.gap, b.GapHistogram, b.GapTotal, ssrc, stream.Name)
+
+		// The counters are cumulative, so the model is fitted in PromQL over
+		// the rate() interval, e.g. p is good_to_bad / good_packets
+		ch <- prometheus.MustNewConstMetric(c.ge, prometheus.CounterValue, float64(b.GoodPackets), ssrc, stream.Name, "good_packets")
+		ch <- prometheus.MustNewConstMetric(c.ge, prometheus.CounterValue, float64(b.GoodLost), ssrc, stream.Name, "good_lost")
+		ch <- prometheus.MustNewConstMetric(c.ge, prometheus.CounterValue, float64(b.BadPackets), ssrc, stream.Name, "bad_packets")
+		ch <- prometheus.MustNewConstMetric(c.ge, prometheus.CounterValue, float64(b.BadReceived), ssrc, stream.Name, "bad_received")
+		ch <- prometheus.MustNewConstMetric(c.ge, prometheus.CounterValue, float64(b.GoodToBad), ssrc, stream.Name, "good_to_bad")
+		ch <- prometheus.MustNewConstMetric(c.ge, prometheus.CounterValue, float64(b.BadToGood), ssrc, stream.Name, "bad_to_good")
+	}
+}
+
+// histogram converts a JumpBuckets histogram
+// Prometheus buckets are cumulative, and the overflow bucket is the +Inf count
+func (c *Collector) histogram(desc *prometheus.Desc, h [goTrackRTP.JumpBucketCount]uint64, sum uint64, labels ...string) prometheus.Metric {
+
+	var cumulative uint64
+	buckets := make(map[float64]uint64, len(c.jumpBuckets))
+	for i, b := range c.jumpBuckets {
+		cumulative += h[i]
+		buckets[b] = cumulative
+	}
+	count := cumulative + h[goTrackRTP.JumpBucketCount-1]
+
+	return prometheus.MustNewConstHistogram(desc, count, float64(sum), buckets, labels...)
+}
@@ -0,0 +1,188 @@
+package promexporter
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+func TestCollector(t *testing.T) {
+
+	tr, err := goTrackRTP.New(10, 10, 10, 10, 0)
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+	s := goTrackRTP.NewSafe(tr)
+
+	for _, seq := range []uint16{0, 1, 3, 2, 2} {
+		_, e := s.PacketArrival(seq)
+		if e != nil {
+			t.Fatalf("%s, e != nil:%v", t.Name(), e)
+		}
+	}
+
+	c := New(Opts{})
+	c.Add(Stream{SSRC: 1234, Name: "test"}, s)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+
+	expected := `
+# HELP gotrackrtp_jump Histogram of the non-zero sequence number jumps
+# TYPE gotrackrtp_jump histogram
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="1"} 3
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="2"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="3"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="5"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="10"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="20"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="50"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="100"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="200"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="500"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="1000"} 4
+gotrackrtp_jump_bucket{ssrc="1234",stream="test",le="+Inf"} 4
+gotrackrtp_jump_sum{ssrc="1234",stream="test"} 5
+gotrackrtp_jump_count{ssrc="1234",stream="test"} 4
+# HELP gotrackrtp_packets_total Packets received
+# TYPE gotrackrtp_packets_total counter
+gotrackrtp_packets_total{ssrc="1234",stream="test"} 5
+# HELP gotrackrtp_taxonomy_packets_total Packets received by position, category, and subcategory
+# TYPE gotrackrtp_taxonomy_packets_total counter
+gotrackrtp_taxonomy_packets_total{category="Unknown",position="Init",ssrc="1234",stream="test",subcategory="Unknown"} 1
+gotrackrtp_taxonomy_packets_total{category="Window",position="Ahead",ssrc="1234",stream="test",subcategory="Jump"} 1
+gotrackrtp_taxonomy_packets_total{category="Window",position="Ahead",ssrc="1234",stream="test",subcategory="Next"} 1
+gotrackrtp_taxonomy_packets_total{category="Window",position="Behind",ssrc="1234",stream="test",subcategory="Duplicate"} 1
+gotrackrtp_taxonomy_packets_total{category="Window",position="Behind",ssrc="1234",stream="test",subcategory="Reordered"} 1
+# HELP gotrackrtp_window_len Packets received within the acceptable window
+# TYPE gotrackrtp_window_len gauge
+gotrackrtp_window_len{ssrc="1234",stream="test"} 4
+# HELP gotrackrtp_window_size Acceptable window size ( aw + bw )
+# TYPE gotrackrtp_window_size gauge
+gotrackrtp_window_size{ssrc="1234",stream="test"} 20
+`
+
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"gotrackrtp_jump", "gotrackrtp_packets_total", "gotrackrtp_taxonomy_packets_total",
+		"gotrackrtp_window_len", "gotrackrtp_window_size")
+	if err != nil {
+		t.Fatalf("%s, GatherAndCompare err:%v", t.Name(), err)
+	}
+
+	// the jitter is only exported once there are timestamps
+	if n := testutil.CollectAndCount(c, "gotrackrtp_jitter_seconds"); n != 0 {
+		t.Fatalf("%s, CollectAndCount(jitter):%d != 0", t.Name(), n)
+	}
+
+	// 18 timestamp units ( 10ms at 1800 Hz ) late, so the jitter is 18/16, which is 1 unit
+	start := time.Unix(1700000000, 0)
+	_, _ = s.PacketArrivalTimestamp(4, 0, 1800, start)
+	_, _ = s.PacketArrivalTimestamp(5, 36, 1800, start.Add(30*time.Millisecond))
+
+	expected = `
+# HELP gotrackrtp_jitter_seconds RFC 3550 interarrival jitter
+# TYPE gotrackrtp_jitter_seconds gauge
+gotrackrtp_jitter_seconds{ssrc="1234",stream="test"} 0.000555555
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "gotrackrtp_jitter_seconds")
+	if err != nil {
+		t.Fatalf("%s, GatherAndCompare err:%v", t.Name(), err)
+	}
+
+	c.Remove(Stream{SSRC: 1234, Name: "test"})
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Fatalf("%s, CollectAndCount:%d != 0 after Remove", t.Name(), n)
+	}
+}
+
+func TestCollectorNamespace(t *testing.T) {
+
+	tr, err := goTrackRTP.New(10, 10, 10, 10, 0)
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+
+	c := New(Opts{Namespace: "probe", Subsystem: "rtp"})
+	c.Add(Stream{SSRC: 1}, goTrackRTP.NewSafe(tr))
+	c.Add(Stream{SSRC: 2}, goTrackRTP.NewSafe(tr))
+
+	// packets, jump, lost, len, window, the jump, burst, and gap histograms, and the
+	// six Gilbert-Elliott counters per stream, with no packets
+	if n := testutil.CollectAndCount(c, "probe_rtp_packets_total"); n != 2 {
+		t.Fatalf("%s, CollectAndCount:%d != 2", t.Name(), n)
+	}
+	if n := testutil.CollectAndCount(c); n != 2*(8+6) {
+		t.Fatalf("%s, CollectAndCount:%d != %d", t.Name(), n, 2*(8+6))
+	}
+}
+
+func TestCollectorBursts(t *testing.T) {
+
+	tr, err := goTrackRTP.New(4, 4, 4, 4, 0)
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+	s := goTrackRTP.NewSafe(tr)
+
+	// 3, 4, and 10 are lost, and 0 to 12 fall off the back of the window
+	for seq := uint16(0); seq <= 20; seq++ {
+		if seq == 3 || seq == 4 || seq == 10 {
+			continue
+		}
+		_, e := s.PacketArrival(seq)
+		if e != nil {
+			t.Fatalf("%s, e != nil:%v", t.Name(), e)
+		}
+	}
+
+	c := New(Opts{})
+	c.Add(Stream{SSRC: 1234, Name: "test"}, s)
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+
+	// 3 and 4 start the bad state, and 5 to 9 are received within Gmin of 10
+	// so p is 1/3, h is 5/8, and 1-k and r are zero (0)
+	expected := `
+# HELP gotrackrtp_gilbert_elliott_total Gilbert-Elliott state counters, to fit the model ( p, r, 1-k, h ) using rate()
+# TYPE gotrackrtp_gilbert_elliott_total counter
+gotrackrtp_gilbert_elliott_total{counter="bad_packets",ssrc="1234",stream="test"} 8
+gotrackrtp_gilbert_elliott_total{counter="bad_received",ssrc="1234",stream="test"} 5
+gotrackrtp_gilbert_elliott_total{counter="bad_to_good",ssrc="1234",stream="test"} 0
+gotrackrtp_gilbert_elliott_total{counter="good_lost",ssrc="1234",stream="test"} 0
+gotrackrtp_gilbert_elliott_total{counter="good_packets",ssrc="1234",stream="test"} 3
+gotrackrtp_gilbert_elliott_total{counter="good_to_bad",ssrc="1234",stream="test"} 1
+# HELP gotrackrtp_loss_burst_length Histogram of the consecutive lost sequence numbers
+# TYPE gotrackrtp_loss_burst_length histogram
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="1"} 1
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="2"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="3"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="5"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="10"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="20"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="50"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="100"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="200"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="500"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="1000"} 2
+gotrackrtp_loss_burst_length_bucket{ssrc="1234",stream="test",le="+Inf"} 2
+gotrackrtp_loss_burst_length_sum{ssrc="1234",stream="test"} 3
+gotrackrtp_loss_burst_length_count{ssrc="1234",stream="test"} 2
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "gotrackrtp_gilbert_elliott_total", "gotrackrtp_loss_burst_length")
+	if err != nil {
+		t.Fatalf("%s, GatherAndCompare err:%v", t.Name(), err)
+	}
+
+	// scraping has no side effects, so a second scrape is the same
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "gotrackrtp_gilbert_elliott_total", "gotrackrtp_loss_burst_length")
+	if err != nil {
+		t.Fatalf("%s, second GatherAndCompare err:%v", t.Name(), err)
+	}
+}
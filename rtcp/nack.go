@@ -0,0 +1,142 @@
+package rtcp
+
+// Generic NACK transport layer feedback message
+//
+// Each Feedback Control Information (FCI) entry is a packet ID (PID), and
+// a bitmask of the following lost packets (BLP), so up to 17 lost packets
+// are requested with each 32 bit entry.
+
+// https://www.rfc-editor.org/rfc/rfc4585#section-6.2.1
+
+//     0                   1                   2                   3
+//     0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |V=2|P| FMT=1   | PT=RTPFB=205  |          length               |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |                  SSRC of packet sender                        |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |                  SSRC of media source                         |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |            PID                |             BLP               |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+import (
+	"encoding/binary"
+)
+
+const (
+	TypeRTPFB = 205
+
+	FormatGenericNACK = 1
+
+	nackPairLen = 4
+	blpBits     = 16
+)
+
+// NACKPair is a Generic NACK FCI entry
+// Bit i of BLP set means PID+i+1 is also lost
+type NACKPair struct {
+	PID uint16
+	BLP uint16
+}
+
+// Seqs returns the sequence numbers requested by the entry
+func (p NACKPair) Seqs() []uint16 {
+
+	seqs := []uint16{p.PID}
+	for i := uint16(0); i < blpBits; i++ {
+		if p.BLP&(1<<i) != 0 {
+			seqs = append(seqs, p.PID+i+1)
+		}
+	}
+
+	return seqs
+}
+
+// NACKPairs packs the sequence numbers into the fewest entries
+// The sequence numbers must be in ascending order, allowing for the wrap,
+// as returned by Tracker.Missing()
+func NACKPairs(seqs []uint16) (pairs []NACKPair) {
+
+	for _, s := range seqs {
+		if len(pairs) > 0 {
+			last := &pairs[len(pairs)-1]
+			d := s - last.PID
+			if d >= 1 && d <= blpBits {
+				last.BLP |= 1 << (d - 1)
+				continue
+			}
+		}
+		pairs = append(pairs, NACKPair{PID: s})
+	}
+
+	return pairs
+}
+
+// GenericNACK is an RTPFB Generic NACK packet
+type GenericNACK struct {
+	SenderSSRC uint32
+	MediaSSRC  uint32
+	Pairs      []NACKPair
+}
+
+// Len returns the packet length in bytes
+func (n *GenericNACK) Len() int {
+	return HeaderLenCst + 8 + len(n.Pairs)*nackPairLen
+}
+
+// AppendTo appends the packet in wire format
+func (n *GenericNACK) AppendTo(b []byte) []byte {
+
+	h := Header{
+		Count:  FormatGenericNACK,
+		Type:   TypeRTPFB,
+		Length: uint16(n.Len()/4 - 1),
+	}
+
+	b = h.AppendTo(b)
+	b = binary.BigEndian.AppendUint32(b, n.SenderSSRC)
+	b = binary.BigEndian.AppendUint32(b, n.MediaSSRC)
+	for _, p := range n.Pairs {
+		b = binary.BigEndian.AppendUint16(b, p.PID)
+		b = binary.BigEndian.AppendUint16(b, p.BLP)
+	}
+
+	return b
+}
+
+// Marshal returns the packet in wire format
+func (n *GenericNACK) Marshal() []byte {
+	return n.AppendTo(make([]byte, 0, n.Len()))
+}
+
+// ParseGenericNACK parses a Generic NACK packet
+func ParseGenericNACK(buf []byte) (GenericNACK, error) {
+
+	h, err := ParseHeader(buf)
+	if err != nil {
+		return GenericNACK{}, err
+	}
+
+	if h.Type != TypeRTPFB || h.Count != FormatGenericNACK {
+		return GenericNACK{}, &ParseError{Err: ErrType, Offset: 0, Len: len(buf)}
+	}
+
+	if h.Len() < HeaderLenCst+8 {
+		return GenericNACK{}, &ParseError{Err: ErrLength, Offset: 2, Len: len(buf)}
+	}
+
+	n := GenericNACK{
+		SenderSSRC: binary.BigEndian.Uint32(buf[4:8]),
+		MediaSSRC:  binary.BigEndian.Uint32(buf[8:12]),
+	}
+
+	for offset := HeaderLenCst + 8; offset+nackPairLen <= h.Len(); offset += nackPairLen {
+		n.Pairs = append(n.Pairs, NACKPair{
+			PID: binary.BigEndian.Uint16(buf[offset : offset+2]),
+			BLP: binary.BigEndian.Uint16(buf[offset+2 : offset+4]),
+		})
+	}
+
+	return n, nil
+}
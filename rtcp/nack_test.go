@@ -0,0 +1,90 @@
+package rtcp
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNACKPairs(t *testing.T) {
+
+	type test struct {
+		name  string
+		seqs  []uint16
+		pairs []NACKPair
+	}
+
+	tests := []test{
+		{"none", nil, nil},
+		{"single", []uint16{10}, []NACKPair{{10, 0}}},
+		{"blp", []uint16{10, 11, 13}, []NACKPair{{10, 0b101}}},
+		{"blp full", []uint16{10, 26}, []NACKPair{{10, 1 << 15}}},
+		{"next pair", []uint16{10, 27}, []NACKPair{{10, 0}, {27, 0}}},
+		{"wrap", []uint16{65534, 65535, 0, 1}, []NACKPair{{65534, 0b111}}},
+	}
+
+	for i, tc := range tests {
+
+		pairs := NACKPairs(tc.seqs)
+		if !reflect.DeepEqual(pairs, tc.pairs) {
+			t.Fatalf("%s, test:%d %s pairs:%v != tc.pairs:%v", t.Name(), i, tc.name, pairs, tc.pairs)
+		}
+
+		var seqs []uint16
+		for _, p := range pairs {
+			seqs = append(seqs, p.Seqs()...)
+		}
+		if !reflect.DeepEqual(seqs, tc.seqs) {
+			t.Fatalf("%s, test:%d %s Seqs():%v != tc.seqs:%v", t.Name(), i, tc.name, seqs, tc.seqs)
+		}
+	}
+}
+
+func TestGenericNACKMarshal(t *testing.T) {
+
+	n := GenericNACK{
+		SenderSSRC: 0x01020304,
+		MediaSSRC:  0x05060708,
+		Pairs:      []NACKPair{{0x1234, 0x8001}, {0x2000, 0}},
+	}
+
+	want := []byte{0x81, 0xCD, 0x00, 0x04,
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		0x12, 0x34, 0x80, 0x01, 0x20, 0x00, 0x00, 0x00}
+
+	buf := n.Marshal()
+	if !reflect.DeepEqual(buf, want) {
+		t.Fatalf("%s, buf:%x != want:%x", t.Name(), buf, want)
+	}
+
+	got, err := ParseGenericNACK(buf)
+	if err != nil {
+		t.Fatalf("%s, ParseGenericNACK err:%v", t.Name(), err)
+	}
+	if !reflect.DeepEqual(got, n) {
+		t.Fatalf("%s, round trip got:%v != n:%v", t.Name(), got, n)
+	}
+
+	type test struct {
+		name string
+		buf  []byte
+		err  error
+	}
+
+	tests := []test{
+		{"rr", []byte{0x80, 0xC9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, ErrType},
+		{"pli", []byte{0x81, 0xCE, 0x00, 0x02, 0, 0, 0, 1, 0, 0, 0, 2}, ErrType},
+		{"length", []byte{0x81, 0xCD, 0x00, 0x01, 0, 0, 0, 1}, ErrLength},
+	}
+
+	for i, tc := range tests {
+		_, err := ParseGenericNACK(tc.buf)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package rtcp
+
+// NACKer decides which missing sequence numbers to request retransmission of
+//
+// A gap is often just reordering, so a sequence number isn't NACKed until
+// it is MinLate positions behind Max().  It is then re-NACKed with an
+// exponential backoff, until it arrives, MaxRetries is reached, or it is
+// more than the behind window behind Max().  A retransmission arriving
+// after that is classified as in the behind buffer, and is not inserted,
+// so it would never be seen to arrive, and would be NACKed forever.
+
+import (
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+const (
+	NACKMinLateCst     = 3
+	NACKIntervalCst    = 20 * time.Millisecond
+	NACKMaxIntervalCst = 500 * time.Millisecond
+)
+
+// MissingSource provides the missing sequence numbers
+// goTrackRTP.Tracker and goTrackRTP.SafeTracker implement MissingSource
+type MissingSource interface {
+	MissingRanges() []goTrackRTP.SeqRange
+	Max() uint16
+	BehindWindow() uint16
+}
+
+// NACKConfig is the retransmission request policy
+type NACKConfig struct {
+	MinLate     uint16        // positions behind Max() before the first NACK, zero (0) defaults to NACKMinLateCst
+	Interval    time.Duration // wait before the first re-NACK, doubling each time, zero (0) defaults to NACKIntervalCst
+	MaxInterval time.Duration // limit for the doubling, zero (0) defaults to NACKMaxIntervalCst
+	MaxRetries  int           // maximum NACKs per sequence number, zero (0) is unlimited
+}
+
+// nackState is the state of a missing sequence number
+type nackState struct {
+	count int       // NACKs sent
+	next  time.Time // when the next NACK is due
+	gen   uint64    // last generation the sequence number was missing
+}
+
+// NACKer holds the per sequence number NACK state for a single stream
+type NACKer struct {
+	config  NACKConfig
+	pending map[uint16]*nackState
+	gen     uint64
+}
+
+// NewNACKer creates a NACKer, applying the defaults
+func NewNACKer(config NACKConfig) *NACKer {
+
+	if config.MinLate == 0 {
+		config.MinLate = NACKMinLateCst
+	}
+	if config.Interval == 0 {
+		config.Interval = NACKIntervalCst
+	}
+	if config.MaxInterval == 0 {
+		config.MaxInterval = NACKMaxIntervalCst
+	}
+
+	return &NACKer{
+		config:  config,
+		pending: make(map[uint16]*nackState),
+	}
+}
+
+// Due returns the sequence numbers to NACK now, in ascending order, ready for NACKPairs()
+// Sequence numbers which have arrived, or are more than the behind window
+// behind Max(), are forgotten
+func (n *NACKer) Due(src MissingSource, now time.Time) (due []uint16) {
+
+	n.gen++
+	max := src.Max()
+	bw := src.BehindWindow()
+
+ranges:
+	for _, r := range src.MissingRanges() {
+		for i, seq := 0, r.Start; i < r.Len(); i, seq = i+1, seq+1 {
+
+			if max-seq < n.config.MinLate {
+				// the rest of the ranges are even closer to Max()
+				break ranges
+			}
+			if max-seq > bw {
+				// a retransmission would land in the behind buffer
+				continue
+			}
+
+			s, ok := n.pending[seq]
+			if !ok {
+				s = &nackState{next: now}
+				n.pending[seq] = s
+			}
+			s.gen = n.gen
+
+			if n.config.MaxRetries > 0 && s.count >= n.config.MaxRetries {
+				continue
+			}
+			if now.Before(s.next) {
+				continue
+			}
+
+			due = append(due, seq)
+			s.next = now.Add(n.backoff(s.count))
+			s.count++
+		}
+	}
+
+	// forget the sequence numbers which are no longer missing
+	for seq, s := range n.pending {
+		if s.gen != n.gen {
+			delete(n.pending, seq)
+		}
+	}
+
+	return due
+}
+
+// backoff returns the wait after the count'th NACK
+func (n *NACKer) backoff(count int) time.Duration {
+
+	d := n.config.Interval
+	for i := 0; i < count && d < n.config.MaxInterval; i++ {
+		d *= 2
+	}
+	if d > n.config.MaxInterval {
+		d = n.config.MaxInterval
+	}
+
+	return d
+}
+
+// Pending returns the number of sequence numbers being tracked
+func (n *NACKer) Pending() int {
+	return len(n.pending)
+}
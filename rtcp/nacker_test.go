@@ -0,0 +1,103 @@
+package rtcp
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+func TestNACKer(t *testing.T) {
+
+	// Each step is the packets arriving, and then Due() at the offset from the start
+	type step struct {
+		seqs    []uint16
+		offset  time.Duration
+		due     []uint16
+		pending int
+	}
+
+	type test struct {
+		name   string
+		window uint16 // aw and bw
+		config NACKConfig
+		steps  []step
+	}
+
+	config := NACKConfig{MinLate: 3, Interval: 10 * time.Millisecond, MaxInterval: 40 * time.Millisecond}
+	ms := time.Millisecond
+
+	tests := []test{
+		{"backoff", 10, config, []step{
+			// 3 is only 1 behind, so it might just be reordered
+			{[]uint16{0, 1, 2, 4}, 0, nil, 0},
+			{[]uint16{5, 6}, 0, []uint16{3}, 1},
+			{[]uint16{7}, 5 * ms, nil, 1},
+			{nil, 10 * ms, []uint16{3}, 1},
+			{nil, 20 * ms, nil, 1},
+			{nil, 30 * ms, []uint16{3}, 1},
+			// capped at MaxInterval
+			{nil, 69 * ms, nil, 1},
+			{nil, 70 * ms, []uint16{3}, 1},
+			{nil, 110 * ms, []uint16{3}, 1},
+			// arrived, so forgotten
+			{[]uint16{3}, 200 * ms, nil, 0},
+		}},
+		{"multiple", 10, config, []step{
+			{[]uint16{0, 2, 5, 6, 9}, 0, []uint16{1, 3, 4}, 3},
+			// 1 is more than bw behind Max(), so is forgotten
+			{[]uint16{4, 12}, 10 * ms, []uint16{3, 7, 8}, 3},
+		}},
+		{"max retries", 10, NACKConfig{MinLate: 3, Interval: 10 * time.Millisecond, MaxRetries: 2}, []step{
+			{[]uint16{0, 1, 2, 4, 5, 6}, 0, []uint16{3}, 1},
+			{nil, 10 * ms, []uint16{3}, 1},
+			{nil, 100 * ms, nil, 1},
+		}},
+		{"left the window", 5, config, []step{
+			{[]uint16{0, 2, 3, 4}, 0, []uint16{1}, 1},
+			// the back of the window is Max() - aw - bw + 1
+			{[]uint16{5, 6, 7, 8, 9, 10, 11}, 100 * ms, nil, 0},
+		}},
+		{"retransmission in the buffer", 5, config, []step{
+			{[]uint16{0, 2, 3, 4}, 0, []uint16{1}, 1},
+			// 1 is still in the window, but more than bw behind Max()
+			{[]uint16{5, 6, 7}, 100 * ms, nil, 0},
+			// so the retransmission lands in the behind buffer, and isn't NACKed again
+			{[]uint16{1}, 200 * ms, nil, 0},
+			{nil, 300 * ms, nil, 0},
+		}},
+	}
+
+	start := time.Unix(1700000000, 0)
+
+	for i, tc := range tests {
+
+		tr, err := goTrackRTP.New(tc.window, tc.window, 10, 10, 0)
+		if err != nil {
+			t.Fatalf("%s, test:%d %s New err:%v", t.Name(), i, tc.name, err)
+		}
+		n := NewNACKer(tc.config)
+
+		for j, s := range tc.steps {
+			for _, seq := range s.seqs {
+				_, e := tr.PacketArrival(seq)
+				if e != nil {
+					t.Fatalf("%s, test:%d %s PacketArrival err:%v", t.Name(), i, tc.name, e)
+				}
+			}
+
+			due := n.Due(tr, start.Add(s.offset))
+			if !reflect.DeepEqual(due, s.due) {
+				t.Fatalf("%s, test:%d %s step:%d due:%v != s.due:%v", t.Name(), i, tc.name, j, due, s.due)
+			}
+			if n.Pending() != s.pending {
+				t.Fatalf("%s, test:%d %s step:%d Pending():%d != s.pending:%d", t.Name(), i, tc.name, j, n.Pending(), s.pending)
+			}
+		}
+	}
+}
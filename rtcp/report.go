@@ -0,0 +1,121 @@
+package rtcp
+
+// Reporter builds reception report blocks from the goTrackRTP RFC 3550
+// source state, keeping the expected_prior and received_prior counts
+// between reports for the fraction lost.
+//
+// https://www.rfc-editor.org/rfc/rfc3550#appendix-A.3
+
+import (
+	"math"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+const (
+	// ntpEpochOffset is the seconds from the NTP epoch (1900) to the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+
+	// maxDLSR is the largest delay since the last SR, just over 18 hours
+	maxDLSR = math.MaxUint32
+)
+
+// Reporter is the reception report state for a single source
+type Reporter struct {
+	SSRC uint32 // source being reported on
+
+	expectedPrior int64
+	receivedPrior uint64
+
+	lsr        uint32
+	lsrArrival time.Time
+}
+
+// NewReporter creates a Reporter for the source
+func NewReporter(ssrc uint32) *Reporter {
+	return &Reporter{
+		SSRC: ssrc,
+	}
+}
+
+// SenderReport records the arrival of a sender report from the source, for
+// the LSR and DLSR fields of the following reports
+func (r *Reporter) SenderReport(ntp uint64, arrival time.Time) {
+	r.lsr = uint32(ntp >> 16)
+	r.lsrArrival = arrival
+}
+
+// Report builds the reception report, and starts the next interval
+// s is the source state, e.g. Tracker.RFC3550(), and jitter is in RTP
+// timestamp units.  Sources which haven't passed probation ( !s.Valid() )
+// should not be reported.
+func (r *Reporter) Report(s goTrackRTP.RFC3550, jitter uint32, now time.Time) ReceptionReport {
+
+	expected := s.Expected()
+	received := s.Received
+
+	// The source state is reset if the source restarts, so restart the interval too
+	if expected < r.expectedPrior || received < r.receivedPrior {
+		r.expectedPrior = 0
+		r.receivedPrior = 0
+	}
+
+	expectedInterval := expected - r.expectedPrior
+	receivedInterval := int64(received - r.receivedPrior)
+	lostInterval := expectedInterval - receivedInterval
+
+	r.expectedPrior = expected
+	r.receivedPrior = received
+
+	var fraction uint8
+	if expectedInterval != 0 && lostInterval > 0 {
+		fraction = uint8((lostInterval << 8) / expectedInterval)
+	}
+
+	lost := s.Lost()
+	if lost > maxCumulativeLost {
+		lost = maxCumulativeLost
+	}
+	if lost < minCumulativeLost {
+		lost = minCumulativeLost
+	}
+
+	return ReceptionReport{
+		SSRC:               r.SSRC,
+		FractionLost:       fraction,
+		CumulativeLost:     int32(lost),
+		ExtendedHighestSeq: s.ExtendedMax(),
+		Jitter:             jitter,
+		LSR:                r.lsr,
+		DLSR:               r.dlsr(now),
+	}
+}
+
+// dlsr returns the delay since the last sender report in units of 1/65536 seconds
+func (r *Reporter) dlsr(now time.Time) uint32 {
+
+	if r.lsrArrival.IsZero() {
+		return 0
+	}
+
+	d := now.Sub(r.lsrArrival)
+	if d <= 0 {
+		return 0
+	}
+	if d >= (maxDLSR>>16)*time.Second {
+		return maxDLSR
+	}
+
+	return uint32(uint64(d) << 16 / uint64(time.Second))
+}
+
+// NTPTime converts the time to the 64 bit NTP timestamp format
+// The high 32 bits are the seconds since 1900, and the low 32 bits the fraction
+func NTPTime(t time.Time) uint64 {
+
+	sec := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+
+	return sec<<32 | frac
+}
@@ -0,0 +1,143 @@
+package rtcp
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+func TestReporter(t *testing.T) {
+
+	// Each step is the packets arriving during the interval, and the report at the end
+	type step struct {
+		seqs               []uint16
+		FractionLost       uint8
+		CumulativeLost     int32
+		ExtendedHighestSeq uint32
+	}
+
+	type test struct {
+		name  string
+		steps []step
+	}
+
+	tests := []test{
+		{"no loss", []step{
+			// the first packet is used for the probation, so the base is 1
+			{[]uint16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 0, 0, 9},
+			{[]uint16{10, 11}, 0, 0, 11},
+		}},
+		{"loss", []step{
+			{[]uint16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 0, 0, 9},
+			// 11 and 14 lost, so 2 of 6
+			{[]uint16{10, 12, 13, 15}, 2 << 8 / 6, 2, 15},
+			// nothing lost this interval
+			{[]uint16{16}, 0, 2, 16},
+			// 17 is reordered within the interval, so nothing lost
+			{[]uint16{18, 17}, 0, 2, 18},
+		}},
+		{"duplicates", []step{
+			{[]uint16{0, 1, 2, 3}, 0, 0, 3},
+			{[]uint16{3, 3, 4}, 0, -2, 4},
+		}},
+		{"wrap", []step{
+			// the base is 65534, so 1 of 5 lost
+			{[]uint16{65533, 65534, 65535, 0, 2}, 1 << 8 / 5, 1, 1<<16 + 2},
+		}},
+		{"empty interval", []step{
+			{[]uint16{0, 1, 2}, 0, 0, 2},
+			{nil, 0, 0, 2},
+		}},
+	}
+
+	for i, tc := range tests {
+
+		tr, err := goTrackRTP.New(10, 10, 10, 10, 0)
+		if err != nil {
+			t.Fatalf("%s, test:%d %s New err:%v", t.Name(), i, tc.name, err)
+		}
+		r := NewReporter(0xCAFE)
+
+		for j, s := range tc.steps {
+			for _, seq := range s.seqs {
+				_, e := tr.PacketArrival(seq)
+				if e != nil {
+					t.Fatalf("%s, test:%d %s PacketArrival err:%v", t.Name(), i, tc.name, e)
+				}
+			}
+
+			rep := r.Report(tr.RFC3550(), 0, time.Now())
+
+			if rep.SSRC != 0xCAFE {
+				t.Fatalf("%s, test:%d %s step:%d SSRC:%x", t.Name(), i, tc.name, j, rep.SSRC)
+			}
+			if rep.FractionLost != s.FractionLost {
+				t.Fatalf("%s, test:%d %s step:%d FractionLost:%d != %d", t.Name(), i, tc.name, j, rep.FractionLost, s.FractionLost)
+			}
+			if rep.CumulativeLost != s.CumulativeLost {
+				t.Fatalf("%s, test:%d %s step:%d CumulativeLost:%d != %d", t.Name(), i, tc.name, j, rep.CumulativeLost, s.CumulativeLost)
+			}
+			if rep.ExtendedHighestSeq != s.ExtendedHighestSeq {
+				t.Fatalf("%s, test:%d %s step:%d ExtendedHighestSeq:%d != %d", t.Name(), i, tc.name, j, rep.ExtendedHighestSeq, s.ExtendedHighestSeq)
+			}
+		}
+	}
+}
+
+func TestReporterDLSR(t *testing.T) {
+
+	arrival := time.Unix(1700000000, 0)
+
+	type test struct {
+		name  string
+		ntp   uint64
+		delay time.Duration
+		LSR   uint32
+		DLSR  uint32
+	}
+
+	tests := []test{
+		{"none", 0, 0, 0, 0},
+		{"1.5s", 0x1122334455667788, 1500 * time.Millisecond, 0x33445566, 3 << 15},
+		{"clock went backwards", 0x1122334455667788, -time.Second, 0x33445566, 0},
+		{"clamped", 0x1122334455667788, 24 * time.Hour, 0x33445566, maxDLSR},
+	}
+
+	for i, tc := range tests {
+
+		r := NewReporter(1)
+		if tc.ntp != 0 {
+			r.SenderReport(tc.ntp, arrival)
+		}
+
+		rep := r.Report(goTrackRTP.RFC3550{}, 0, arrival.Add(tc.delay))
+		if rep.LSR != tc.LSR || rep.DLSR != tc.DLSR {
+			t.Fatalf("%s, test:%d %s LSR:%x DLSR:%d != tc.LSR:%x tc.DLSR:%d", t.Name(), i, tc.name, rep.LSR, rep.DLSR, tc.LSR, tc.DLSR)
+		}
+	}
+}
+
+func TestNTPTime(t *testing.T) {
+
+	type test struct {
+		t   time.Time
+		ntp uint64
+	}
+
+	tests := []test{
+		{time.Unix(0, 0), ntpEpochOffset << 32},
+		{time.Unix(1, 500000000), (ntpEpochOffset+1)<<32 | 0x80000000},
+		{time.Unix(2, 250000000), (ntpEpochOffset+2)<<32 | 0x40000000},
+	}
+
+	for i, tc := range tests {
+		if ntp := NTPTime(tc.t); ntp != tc.ntp {
+			t.Fatalf("%s, test:%d NTPTime:%x != tc.ntp:%x", t.Name(), i, ntp, tc.ntp)
+		}
+	}
+}
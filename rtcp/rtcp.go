@@ -0,0 +1,252 @@
+package rtcp
+
+// rtcp builds and parses RTCP packets
+//
+// Marshal appends to a caller supplied buffer, so a compound packet can be
+// built in a single buffer without allocating.
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// https://www.rfc-editor.org/rfc/rfc3550#section-6.4.2
+
+//         0                   1                   2                   3
+//         0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+// header |V=2|P|    RC   |   PT=RR=201   |             length            |
+//        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//        |                     SSRC of packet sender                     |
+//        +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+// report |                 SSRC_1 (SSRC of first source)                 |
+// block  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//   1    | fraction lost |       cumulative number of packets lost       |
+//        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//        |           extended highest sequence number received           |
+//        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//        |                      interarrival jitter                      |
+//        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//        |                         last SR (LSR)                         |
+//        +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//        |                   delay since last SR (DLSR)                  |
+//        +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	VersionCst = 2
+
+	HeaderLenCst          = 4
+	ReceptionReportLenCst = 24
+
+	// MaxCountCst is the maximum of the 5 bit count field
+	MaxCountCst = 31
+
+	// Packet types
+	TypeSR = 200
+	TypeRR = 201
+
+	// cumulative lost is a 24 bit signed integer
+	maxCumulativeLost = 0x7FFFFF
+	minCumulativeLost = -0x800000
+)
+
+var (
+	ErrHeaderShort   = errors.New("ErrHeaderShort packet shorter than the header")
+	ErrVersion       = errors.New("ErrVersion unsupported RTCP version")
+	ErrType          = errors.New("ErrType unexpected RTCP packet type")
+	ErrLength        = errors.New("ErrLength packet shorter than the header length")
+	ErrTooManyBlocks = errors.New("ErrTooManyBlocks more than MaxCountCst blocks")
+)
+
+// ParseError describes a malformed packet
+// Use errors.Is to match the underlying Err
+type ParseError struct {
+	Err    error
+	Offset int // offset in the buffer where the problem was found
+	Len    int // length of the buffer
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("rtcp: %v, offset:%d, len:%d", e.Err, e.Offset, e.Len)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Header is the common RTCP header
+type Header struct {
+	Padding bool
+	Count   uint8  // reception report count, or the feedback message type
+	Type    uint8  // packet type
+	Length  uint16 // length in 32 bit words minus one, including the header
+}
+
+// AppendTo appends the header
+func (h *Header) AppendTo(b []byte) []byte {
+
+	first := uint8(VersionCst<<6) | h.Count&MaxCountCst
+	if h.Padding {
+		first |= 0x20
+	}
+
+	b = append(b, first, h.Type)
+
+	return binary.BigEndian.AppendUint16(b, h.Length)
+}
+
+// ParseHeader parses the common header, checking the buffer is at least
+// as long as the header length
+func ParseHeader(buf []byte) (Header, error) {
+
+	if len(buf) < HeaderLenCst {
+		return Header{}, &ParseError{Err: ErrHeaderShort, Offset: 0, Len: len(buf)}
+	}
+
+	if buf[0]>>6 != VersionCst {
+		return Header{}, &ParseError{Err: ErrVersion, Offset: 0, Len: len(buf)}
+	}
+
+	h := Header{
+		Padding: buf[0]&0x20 != 0,
+		Count:   buf[0] & MaxCountCst,
+		Type:    buf[1],
+		Length:  binary.BigEndian.Uint16(buf[2:4]),
+	}
+
+	if len(buf) < h.Len() {
+		return Header{}, &ParseError{Err: ErrLength, Offset: 2, Len: len(buf)}
+	}
+
+	return h, nil
+}
+
+// Len returns the packet length in bytes
+func (h *Header) Len() int {
+	return (int(h.Length) + 1) * 4
+}
+
+// ReceptionReport is a report block, from RFC 3550 Section 6.4.1
+type ReceptionReport struct {
+	SSRC               uint32 // source this report is about
+	FractionLost       uint8  // fraction lost since the previous report, as a fixed point number with the binary point at the left edge
+	CumulativeLost     int32  // 24 bit signed, negative if there are duplicates
+	ExtendedHighestSeq uint32 // cycles in the high 16 bits, and the highest sequence number in the low 16 bits
+	Jitter             uint32 // interarrival jitter in timestamp units
+	LSR                uint32 // middle 32 bits of the NTP timestamp of the last sender report, zero (0) if none
+	DLSR               uint32 // delay since the last sender report in units of 1/65536 seconds
+}
+
+// AppendTo appends the report block
+// CumulativeLost is clamped to 24 bits
+func (r *ReceptionReport) AppendTo(b []byte) []byte {
+
+	lost := r.CumulativeLost
+	if lost > maxCumulativeLost {
+		lost = maxCumulativeLost
+	}
+	if lost < minCumulativeLost {
+		lost = minCumulativeLost
+	}
+
+	b = binary.BigEndian.AppendUint32(b, r.SSRC)
+	b = binary.BigEndian.AppendUint32(b, uint32(r.FractionLost)<<24|uint32(lost)&0xFFFFFF)
+	b = binary.BigEndian.AppendUint32(b, r.ExtendedHighestSeq)
+	b = binary.BigEndian.AppendUint32(b, r.Jitter)
+	b = binary.BigEndian.AppendUint32(b, r.LSR)
+
+	return binary.BigEndian.AppendUint32(b, r.DLSR)
+}
+
+// parseReceptionReport parses a report block, which must be ReceptionReportLenCst long
+func parseReceptionReport(buf []byte) ReceptionReport {
+
+	lost := binary.BigEndian.Uint32(buf[4:8]) & 0xFFFFFF
+	// sign extend the 24 bits
+	cumulativeLost := int32(lost<<8) >> 8
+
+	return ReceptionReport{
+		SSRC:               binary.BigEndian.Uint32(buf[0:4]),
+		FractionLost:       buf[4],
+		CumulativeLost:     cumulativeLost,
+		ExtendedHighestSeq: binary.BigEndian.Uint32(buf[8:12]),
+		Jitter:             binary.BigEndian.Uint32(buf[12:16]),
+		LSR:                binary.BigEndian.Uint32(buf[16:20]),
+		DLSR:               binary.BigEndian.Uint32(buf[20:24]),
+	}
+}
+
+// ReceiverReport is an RTCP RR packet
+type ReceiverReport struct {
+	SSRC    uint32 // SSRC of the packet sender
+	Reports []ReceptionReport
+}
+
+// Len returns the packet length in bytes
+func (rr *ReceiverReport) Len() int {
+	return HeaderLenCst + 4 + len(rr.Reports)*ReceptionReportLenCst
+}
+
+// AppendTo appends the packet in wire format
+// More than MaxCountCst reports must be split across multiple packets
+func (rr *ReceiverReport) AppendTo(b []byte) ([]byte, error) {
+
+	if len(rr.Reports) > MaxCountCst {
+		return b, ErrTooManyBlocks
+	}
+
+	h := Header{
+		Count:  uint8(len(rr.Reports)),
+		Type:   TypeRR,
+		Length: uint16(rr.Len()/4 - 1),
+	}
+
+	b = h.AppendTo(b)
+	b = binary.BigEndian.AppendUint32(b, rr.SSRC)
+	for i := range rr.Reports {
+		b = rr.Reports[i].AppendTo(b)
+	}
+
+	return b, nil
+}
+
+// Marshal returns the packet in wire format
+func (rr *ReceiverReport) Marshal() ([]byte, error) {
+	return rr.AppendTo(make([]byte, 0, rr.Len()))
+}
+
+// ParseReceiverReport parses an RR packet
+// Any profile specific extension after the report blocks is ignored
+func ParseReceiverReport(buf []byte) (ReceiverReport, error) {
+
+	h, err := ParseHeader(buf)
+	if err != nil {
+		return ReceiverReport{}, err
+	}
+
+	if h.Type != TypeRR {
+		return ReceiverReport{}, &ParseError{Err: ErrType, Offset: 1, Len: len(buf)}
+	}
+
+	need := HeaderLenCst + 4 + int(h.Count)*ReceptionReportLenCst
+	if h.Len() < need {
+		return ReceiverReport{}, &ParseError{Err: ErrLength, Offset: 2, Len: len(buf)}
+	}
+
+	rr := ReceiverReport{
+		SSRC: binary.BigEndian.Uint32(buf[4:8]),
+	}
+
+	if h.Count > 0 {
+		rr.Reports = make([]ReceptionReport, h.Count)
+		for i := range rr.Reports {
+			offset := HeaderLenCst + 4 + i*ReceptionReportLenCst
+			rr.Reports[i] = parseReceptionReport(buf[offset : offset+ReceptionReportLenCst])
+		}
+	}
+
+	return rr, nil
+}
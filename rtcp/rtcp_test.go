@@ -0,0 +1,115 @@
+package rtcp
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReceiverReportMarshal(t *testing.T) {
+
+	type test struct {
+		name string
+		rr   ReceiverReport
+		buf  []byte
+	}
+
+	tests := []test{
+		{"empty",
+			ReceiverReport{SSRC: 0x01020304},
+			[]byte{0x80, 0xC9, 0x00, 0x01, 0x01, 0x02, 0x03, 0x04}},
+		{"one block",
+			ReceiverReport{SSRC: 0x01020304, Reports: []ReceptionReport{
+				{0x0A0B0C0D, 0x40, 5, 0x00010005, 0x10, 0x12345678, 0x00010000}}},
+			[]byte{0x81, 0xC9, 0x00, 0x07, 0x01, 0x02, 0x03, 0x04,
+				0x0A, 0x0B, 0x0C, 0x0D, 0x40, 0x00, 0x00, 0x05, 0x00, 0x01, 0x00, 0x05,
+				0x00, 0x00, 0x00, 0x10, 0x12, 0x34, 0x56, 0x78, 0x00, 0x01, 0x00, 0x00}},
+		{"negative lost",
+			ReceiverReport{SSRC: 1, Reports: []ReceptionReport{{SSRC: 2, CumulativeLost: -2}}},
+			[]byte{0x81, 0xC9, 0x00, 0x07, 0x00, 0x00, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x02, 0x00, 0xFF, 0xFF, 0xFE, 0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+
+	for i, tc := range tests {
+
+		buf, err := tc.rr.Marshal()
+		if err != nil {
+			t.Fatalf("%s, test:%d %s Marshal err:%v", t.Name(), i, tc.name, err)
+		}
+		if !reflect.DeepEqual(buf, tc.buf) {
+			t.Fatalf("%s, test:%d %s buf:%x != tc.buf:%x", t.Name(), i, tc.name, buf, tc.buf)
+		}
+
+		rr, err := ParseReceiverReport(buf)
+		if err != nil {
+			t.Fatalf("%s, test:%d %s ParseReceiverReport err:%v", t.Name(), i, tc.name, err)
+		}
+		if !reflect.DeepEqual(rr, tc.rr) {
+			t.Fatalf("%s, test:%d %s round trip rr:%v != tc.rr:%v", t.Name(), i, tc.name, rr, tc.rr)
+		}
+	}
+}
+
+func TestReceptionReportClamp(t *testing.T) {
+
+	type test struct {
+		lost int32
+		want int32
+	}
+
+	tests := []test{
+		{maxCumulativeLost, maxCumulativeLost},
+		{maxCumulativeLost + 1, maxCumulativeLost},
+		{minCumulativeLost, minCumulativeLost},
+		{minCumulativeLost - 1, minCumulativeLost},
+	}
+
+	for i, tc := range tests {
+		r := ReceptionReport{CumulativeLost: tc.lost}
+		got := parseReceptionReport(r.AppendTo(nil))
+		if got.CumulativeLost != tc.want {
+			t.Fatalf("%s, test:%d CumulativeLost:%d != tc.want:%d", t.Name(), i, got.CumulativeLost, tc.want)
+		}
+	}
+}
+
+func TestParseReceiverReportErrors(t *testing.T) {
+
+	type test struct {
+		name string
+		buf  []byte
+		err  error
+	}
+
+	tests := []test{
+		{"short", []byte{0x80, 0xC9}, ErrHeaderShort},
+		{"version", []byte{0x40, 0xC9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, ErrVersion},
+		{"type", []byte{0x80, 0xC8, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, ErrType},
+		{"length", []byte{0x80, 0xC9, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01}, ErrLength},
+		{"count", []byte{0x81, 0xC9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, ErrLength},
+	}
+
+	for i, tc := range tests {
+
+		_, err := ParseReceiverReport(tc.buf)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%s, test:%d %s err:%v is not a *ParseError", t.Name(), i, tc.name, err)
+		}
+	}
+
+	rr := ReceiverReport{Reports: make([]ReceptionReport, MaxCountCst+1)}
+	_, err := rr.Marshal()
+	if !errors.Is(err, ErrTooManyBlocks) {
+		t.Fatalf("%s, Marshal err:%v != ErrTooManyBlocks", t.Name(), err)
+	}
+}
@@ -0,0 +1,481 @@
+package rtcp
+
+// RTCP Extended Reports ( XR )
+//
+// An XR packet is the sender SSRC followed by any number of report blocks,
+// each with its own block type ( BT ) and length.  These blocks are
+// implemented:
+//
+//	BT=1 Loss RLE, which sequence numbers were received
+//	BT=2 Duplicate RLE, which sequence numbers were duplicated
+//	BT=3 Packet Receipt Times
+//	BT=6 Statistics Summary
+//
+// The RLE blocks are a series of 16 bit chunks.  A run length chunk is a
+// run of up to 16383 sequence numbers with the same value, and a bit
+// vector chunk has the values of the next 15 sequence numbers, most
+// significant bit first.  A null chunk pads to the 32 bit boundary.
+//
+// The sequence number range is [BeginSeq, EndSeq), and with thinning ( T )
+// only the sequence numbers which are multiples of 2^T are included.
+
+// https://www.rfc-editor.org/rfc/rfc3611#section-4
+
+//     0                   1                   2                   3
+//     0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |V=2|P|reserved |   PT=XR=207   |             length            |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |                              SSRC                             |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    :                         report blocks                         :
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |      BT       | type-specific |         block length          |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    :             type-specific block contents                      :
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+import (
+	"encoding/binary"
+)
+
+const (
+	TypeXR = 207
+
+	// XR block types
+	BlockLossRLE           = 1
+	BlockDuplicateRLE      = 2
+	BlockReceiptTimes      = 3
+	BlockStatisticsSummary = 6
+
+	// Statistics Summary type of hop ( ToH )
+	ToHNone = 0
+	ToHIPv4 = 1 // TTL
+	ToHIPv6 = 2 // Hop Limit
+
+	xrBlockHeaderLen       = 4
+	statisticsSummaryWords = 9
+
+	// MaxRunLengthCst is the longest run in a run length chunk
+	MaxRunLengthCst = 0x3FFF
+
+	// ChunkBitsCst is the number of values in a bit vector chunk
+	ChunkBitsCst = 15
+
+	chunkBitVector = 0x8000
+	chunkRunOne    = 0x4000
+)
+
+// Chunk is an RLE chunk
+type Chunk uint16
+
+// RunChunk returns a run length chunk
+func RunChunk(value bool, length uint16) Chunk {
+	c := Chunk(length & MaxRunLengthCst)
+	if value {
+		c |= chunkRunOne
+	}
+	return c
+}
+
+// IsNull returns true for the terminating null chunk
+func (c Chunk) IsNull() bool {
+	return c == 0
+}
+
+// IsBitVector returns true for a bit vector chunk, and false for a run length chunk
+func (c Chunk) IsBitVector() bool {
+	return c&chunkBitVector != 0
+}
+
+// EncodeChunks run length encodes the values
+// Runs of at least ChunkBitsCst, or reaching the end, are run length
+// chunks, and the rest are bit vectors.  The bits of the final bit vector
+// past the end are zero (0).
+func EncodeChunks(values []bool) (chunks []Chunk) {
+
+	for i := 0; i < len(values); {
+
+		run := 1
+		for i+run < len(values) && run < MaxRunLengthCst && values[i+run] == values[i] {
+			run++
+		}
+
+		if run >= ChunkBitsCst || i+run == len(values) {
+			chunks = append(chunks, RunChunk(values[i], uint16(run)))
+			i += run
+			continue
+		}
+
+		c := Chunk(chunkBitVector)
+		for b := 0; b < ChunkBitsCst && i < len(values); b, i = b+1, i+1 {
+			if values[i] {
+				c |= 1 << (ChunkBitsCst - 1 - b)
+			}
+		}
+		chunks = append(chunks, c)
+	}
+
+	return chunks
+}
+
+// DecodeChunks expands the chunks into n values, stopping at a null chunk
+func DecodeChunks(chunks []Chunk, n int) []bool {
+
+	values := make([]bool, 0, n)
+
+	for _, c := range chunks {
+		if c.IsNull() {
+			break
+		}
+		if c.IsBitVector() {
+			for b := 0; b < ChunkBitsCst; b++ {
+				values = append(values, c&(1<<(ChunkBitsCst-1-b)) != 0)
+			}
+			continue
+		}
+		value := c&chunkRunOne != 0
+		for i := 0; i < int(c&MaxRunLengthCst); i++ {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) > n {
+		values = values[:n]
+	}
+
+	return values
+}
+
+// XRBlock is an XR report block
+type XRBlock interface {
+	BlockType() uint8
+	Len() int // length in bytes, including the block header
+	AppendTo(b []byte) []byte
+}
+
+// appendBlockHeader appends the block header, with the length in 32 bit words minus one
+func appendBlockHeader(b []byte, bt uint8, typeSpecific uint8, length int) []byte {
+	b = append(b, bt, typeSpecific)
+	return binary.BigEndian.AppendUint16(b, uint16(length/4-1))
+}
+
+// RLEBlock is a Loss RLE, or Duplicate RLE, report block
+// For Loss RLE a one (1) is received, and for Duplicate RLE a one (1) is duplicated
+type RLEBlock struct {
+	Type     uint8 // BlockLossRLE or BlockDuplicateRLE
+	Thinning uint8 // T, 4 bits
+	SSRC     uint32
+	BeginSeq uint16
+	EndSeq   uint16 // exclusive
+	Chunks   []Chunk
+}
+
+func (r *RLEBlock) BlockType() uint8 {
+	return r.Type
+}
+
+// Len returns the length in bytes, with the chunks padded to 32 bits
+func (r *RLEBlock) Len() int {
+	return xrBlockHeaderLen + 8 + (len(r.Chunks)+1)/2*4
+}
+
+// AppendTo appends the block in wire format
+func (r *RLEBlock) AppendTo(b []byte) []byte {
+
+	b = appendBlockHeader(b, r.Type, r.Thinning&0x0F, r.Len())
+	b = binary.BigEndian.AppendUint32(b, r.SSRC)
+	b = binary.BigEndian.AppendUint16(b, r.BeginSeq)
+	b = binary.BigEndian.AppendUint16(b, r.EndSeq)
+	for _, c := range r.Chunks {
+		b = binary.BigEndian.AppendUint16(b, uint16(c))
+	}
+	if len(r.Chunks)%2 != 0 {
+		b = binary.BigEndian.AppendUint16(b, 0)
+	}
+
+	return b
+}
+
+// Values returns the value of each sequence number, see DecodeChunks
+// With thinning, the values are only for the multiples of 2^T
+func (r *RLEBlock) Values() []bool {
+
+	var n int
+	mask := uint16(1)<<(r.Thinning&0x0F) - 1
+	for seq := r.BeginSeq; seq != r.EndSeq; seq++ {
+		if seq&mask == 0 {
+			n++
+		}
+	}
+
+	return DecodeChunks(r.Chunks, n)
+}
+
+// ReceiptTimesBlock is a Packet Receipt Times report block
+// The times are in RTP timestamp units, and are zero (0) if not received
+type ReceiptTimesBlock struct {
+	Thinning uint8 // T, 4 bits
+	SSRC     uint32
+	BeginSeq uint16
+	EndSeq   uint16 // exclusive
+	Times    []uint32
+}
+
+func (r *ReceiptTimesBlock) BlockType() uint8 {
+	return BlockReceiptTimes
+}
+
+func (r *ReceiptTimesBlock) Len() int {
+	return xrBlockHeaderLen + 8 + len(r.Times)*4
+}
+
+// AppendTo appends the block in wire format
+func (r *ReceiptTimesBlock) AppendTo(b []byte) []byte {
+
+	b = appendBlockHeader(b, BlockReceiptTimes, r.Thinning&0x0F, r.Len())
+	b = binary.BigEndian.AppendUint32(b, r.SSRC)
+	b = binary.BigEndian.AppendUint16(b, r.BeginSeq)
+	b = binary.BigEndian.AppendUint16(b, r.EndSeq)
+	for _, t := range r.Times {
+		b = binary.BigEndian.AppendUint32(b, t)
+	}
+
+	return b
+}
+
+// StatisticsSummaryBlock is a Statistics Summary report block
+// The jitter is the relative transit time between packets, in RTP
+// timestamp units, and the TTL, or Hop Limit, is selected by ToH
+type StatisticsSummaryBlock struct {
+	LossReport      bool // L, Lost is valid
+	DuplicateReport bool // D, Duplicates is valid
+	JitterReport    bool // J, the jitter is valid
+	ToH             uint8
+
+	SSRC       uint32
+	BeginSeq   uint16
+	EndSeq     uint16 // exclusive
+	Lost       uint32
+	Duplicates uint32
+
+	MinJitter  uint32
+	MaxJitter  uint32
+	MeanJitter uint32
+	DevJitter  uint32
+
+	MinTTL  uint8
+	MaxTTL  uint8
+	MeanTTL uint8
+	DevTTL  uint8
+}
+
+func (s *StatisticsSummaryBlock) BlockType() uint8 {
+	return BlockStatisticsSummary
+}
+
+func (s *StatisticsSummaryBlock) Len() int {
+	return xrBlockHeaderLen + statisticsSummaryWords*4
+}
+
+// flags returns the type-specific byte
+func (s *StatisticsSummaryBlock) flags() (f uint8) {
+	if s.LossReport {
+		f |= 0x80
+	}
+	if s.DuplicateReport {
+		f |= 0x40
+	}
+	if s.JitterReport {
+		f |= 0x20
+	}
+	return f | (s.ToH&0x03)<<3
+}
+
+// AppendTo appends the block in wire format
+func (s *StatisticsSummaryBlock) AppendTo(b []byte) []byte {
+
+	b = appendBlockHeader(b, BlockStatisticsSummary, s.flags(), s.Len())
+	b = binary.BigEndian.AppendUint32(b, s.SSRC)
+	b = binary.BigEndian.AppendUint16(b, s.BeginSeq)
+	b = binary.BigEndian.AppendUint16(b, s.EndSeq)
+	b = binary.BigEndian.AppendUint32(b, s.Lost)
+	b = binary.BigEndian.AppendUint32(b, s.Duplicates)
+	b = binary.BigEndian.AppendUint32(b, s.MinJitter)
+	b = binary.BigEndian.AppendUint32(b, s.MaxJitter)
+	b = binary.BigEndian.AppendUint32(b, s.MeanJitter)
+	b = binary.BigEndian.AppendUint32(b, s.DevJitter)
+
+	return append(b, s.MinTTL, s.MaxTTL, s.MeanTTL, s.DevTTL)
+}
+
+// UnknownXRBlock is a report block of a type which isn't implemented
+type UnknownXRBlock struct {
+	Type         uint8
+	TypeSpecific uint8
+	Contents     []byte // after the block header, a multiple of 4 bytes
+}
+
+func (u *UnknownXRBlock) BlockType() uint8 {
+	return u.Type
+}
+
+func (u *UnknownXRBlock) Len() int {
+	return xrBlockHeaderLen + len(u.Contents)
+}
+
+// AppendTo appends the block in wire format
+func (u *UnknownXRBlock) AppendTo(b []byte) []byte {
+	b = appendBlockHeader(b, u.Type, u.TypeSpecific, u.Len())
+	return append(b, u.Contents...)
+}
+
+// ExtendedReport is an RTCP XR packet
+type ExtendedReport struct {
+	SSRC   uint32 // SSRC of the packet sender
+	Blocks []XRBlock
+}
+
+// Len returns the packet length in bytes
+func (x *ExtendedReport) Len() int {
+	n := HeaderLenCst + 4
+	for _, b := range x.Blocks {
+		n += b.Len()
+	}
+	return n
+}
+
+// AppendTo appends the packet in wire format
+func (x *ExtendedReport) AppendTo(b []byte) []byte {
+
+	h := Header{
+		Type:   TypeXR,
+		Length: uint16(x.Len()/4 - 1),
+	}
+
+	b = h.AppendTo(b)
+	b = binary.BigEndian.AppendUint32(b, x.SSRC)
+	for _, blk := range x.Blocks {
+		b = blk.AppendTo(b)
+	}
+
+	return b
+}
+
+// Marshal returns the packet in wire format
+func (x *ExtendedReport) Marshal() []byte {
+	return x.AppendTo(make([]byte, 0, x.Len()))
+}
+
+// ParseExtendedReport parses an XR packet
+// The blocks which aren't implemented are returned as UnknownXRBlock
+func ParseExtendedReport(buf []byte) (ExtendedReport, error) {
+
+	h, err := ParseHeader(buf)
+	if err != nil {
+		return ExtendedReport{}, err
+	}
+
+	if h.Type != TypeXR {
+		return ExtendedReport{}, &ParseError{Err: ErrType, Offset: 1, Len: len(buf)}
+	}
+
+	if h.Len() < HeaderLenCst+4 {
+		return ExtendedReport{}, &ParseError{Err: ErrLength, Offset: 2, Len: len(buf)}
+	}
+
+	x := ExtendedReport{
+		SSRC: binary.BigEndian.Uint32(buf[4:8]),
+	}
+
+	for offset := HeaderLenCst + 4; offset < h.Len(); {
+
+		if offset+xrBlockHeaderLen > h.Len() {
+			return ExtendedReport{}, &ParseError{Err: ErrLength, Offset: offset, Len: len(buf)}
+		}
+		end := offset + (int(binary.BigEndian.Uint16(buf[offset+2:offset+4]))+1)*4
+		if end > h.Len() {
+			return ExtendedReport{}, &ParseError{Err: ErrLength, Offset: offset + 2, Len: len(buf)}
+		}
+
+		blk, err := parseXRBlock(buf[offset:end])
+		if err != nil {
+			return ExtendedReport{}, &ParseError{Err: err, Offset: offset, Len: len(buf)}
+		}
+		x.Blocks = append(x.Blocks, blk)
+
+		offset = end
+	}
+
+	return x, nil
+}
+
+// parseXRBlock parses a single block, which is the block length long
+func parseXRBlock(buf []byte) (XRBlock, error) {
+
+	bt := buf[0]
+	typeSpecific := buf[1]
+	contents := buf[xrBlockHeaderLen:]
+
+	switch bt {
+	case BlockLossRLE, BlockDuplicateRLE, BlockReceiptTimes:
+
+		if len(contents) < 8 {
+			return nil, ErrLength
+		}
+		ssrc := binary.BigEndian.Uint32(contents[0:4])
+		begin := binary.BigEndian.Uint16(contents[4:6])
+		end := binary.BigEndian.Uint16(contents[6:8])
+
+		if bt == BlockReceiptTimes {
+			r := &ReceiptTimesBlock{Thinning: typeSpecific & 0x0F, SSRC: ssrc, BeginSeq: begin, EndSeq: end}
+			for i := 8; i+4 <= len(contents); i += 4 {
+				r.Times = append(r.Times, binary.BigEndian.Uint32(contents[i:i+4]))
+			}
+			return r, nil
+		}
+
+		r := &RLEBlock{Type: bt, Thinning: typeSpecific & 0x0F, SSRC: ssrc, BeginSeq: begin, EndSeq: end}
+		for i := 8; i+2 <= len(contents); i += 2 {
+			c := Chunk(binary.BigEndian.Uint16(contents[i : i+2]))
+			if c.IsNull() {
+				break
+			}
+			r.Chunks = append(r.Chunks, c)
+		}
+		return r, nil
+
+	case BlockStatisticsSummary:
+
+		if len(contents) < statisticsSummaryWords*4 {
+			return nil, ErrLength
+		}
+		return &StatisticsSummaryBlock{
+			LossReport:      typeSpecific&0x80 != 0,
+			DuplicateReport: typeSpecific&0x40 != 0,
+			JitterReport:    typeSpecific&0x20 != 0,
+			ToH:             typeSpecific >> 3 & 0x03,
+			SSRC:            binary.BigEndian.Uint32(contents[0:4]),
+			BeginSeq:        binary.BigEndian.Uint16(contents[4:6]),
+			EndSeq:          binary.BigEndian.Uint16(contents[6:8]),
+			Lost:            binary.BigEndian.Uint32(contents[8:12]),
+			Duplicates:      binary.BigEndian.Uint32(contents[12:16]),
+			MinJitter:       binary.BigEndian.Uint32(contents[16:20]),
+			MaxJitter:       binary.BigEndian.Uint32(contents[20:24]),
+			MeanJitter:      binary.BigEndian.Uint32(contents[24:28]),
+			DevJitter:       binary.BigEndian.Uint32(contents[28:32]),
+			MinTTL:          contents[32],
+			MaxTTL:          contents[33],
+			MeanTTL:         contents[34],
+			DevTTL:          contents[35],
+		}, nil
+	}
+
+	return &UnknownXRBlock{
+		Type:         bt,
+		TypeSpecific: typeSpecific,
+		Contents:     append([]byte(nil), contents...),
+	}, nil
+}
@@ -0,0 +1,127 @@
+package rtcp
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// bits converts "1" and "0" to values
+func bits(s string) (values []bool) {
+	for _, c := range s {
+		values = append(values, c == '1')
+	}
+	return values
+}
+
+func TestEncodeChunks(t *testing.T) {
+
+	type test struct {
+		name   string
+		values []bool
+		chunks []Chunk
+	}
+
+	tests := []test{
+		{"none", nil, nil},
+		{"short run", bits("111"), []Chunk{0x4003}},
+		{"lost run", bits(strings.Repeat("0", 20)), []Chunk{0x0014}},
+		{"long run", bits(strings.Repeat("1", MaxRunLengthCst+1)), []Chunk{0x7FFF, 0x4001}},
+		// 15 values, most significant bit first
+		{"bit vector", bits("101111111111111"), []Chunk{0xDFFF}},
+		// the final bit vector is padded with zeros
+		{"short bit vector", bits("1011"), []Chunk{0xD800}},
+		{"vector then run", bits("110" + strings.Repeat("1", 12) + strings.Repeat("0", 15)), []Chunk{0xEFFF, 0x000F}},
+		{"run then vector", bits(strings.Repeat("1", 15) + "0101"), []Chunk{0x400F, 0xA800}},
+	}
+
+	for i, tc := range tests {
+
+		chunks := EncodeChunks(tc.values)
+		if !reflect.DeepEqual(chunks, tc.chunks) {
+			t.Fatalf("%s, test:%d %s chunks:%x != tc.chunks:%x", t.Name(), i, tc.name, chunks, tc.chunks)
+		}
+
+		values := DecodeChunks(chunks, len(tc.values))
+		if len(values) != len(tc.values) || (len(values) > 0 && !reflect.DeepEqual(values, tc.values)) {
+			t.Fatalf("%s, test:%d %s round trip values:%v != tc.values:%v", t.Name(), i, tc.name, values, tc.values)
+		}
+	}
+}
+
+func TestExtendedReportMarshal(t *testing.T) {
+
+	x := ExtendedReport{
+		SSRC: 0x01020304,
+		Blocks: []XRBlock{
+			&RLEBlock{Type: BlockLossRLE, SSRC: 0x05060708, BeginSeq: 10, EndSeq: 13, Chunks: []Chunk{0xA000}},
+			&RLEBlock{Type: BlockDuplicateRLE, Thinning: 1, SSRC: 0x05060708, BeginSeq: 10, EndSeq: 14, Chunks: []Chunk{0x0001, 0x4001}},
+			&ReceiptTimesBlock{SSRC: 0x05060708, BeginSeq: 10, EndSeq: 12, Times: []uint32{0x11223344, 0}},
+			&StatisticsSummaryBlock{LossReport: true, DuplicateReport: true, JitterReport: true, ToH: ToHIPv4,
+				SSRC: 0x05060708, BeginSeq: 10, EndSeq: 13, Lost: 1, Duplicates: 2,
+				MinJitter: 3, MaxJitter: 4, MeanJitter: 5, DevJitter: 6,
+				MinTTL: 60, MaxTTL: 64, MeanTTL: 62, DevTTL: 1},
+			&UnknownXRBlock{Type: 4, TypeSpecific: 0, Contents: []byte{0, 0, 0, 9, 0, 0, 0, 1}},
+		},
+	}
+
+	want := []byte{0x80, 0xCF, 0x00, 0x1B, 0x01, 0x02, 0x03, 0x04,
+		// Loss RLE, padded with a null chunk
+		0x01, 0x00, 0x00, 0x03, 0x05, 0x06, 0x07, 0x08, 0x00, 0x0A, 0x00, 0x0D, 0xA0, 0x00, 0x00, 0x00,
+		// Duplicate RLE
+		0x02, 0x01, 0x00, 0x03, 0x05, 0x06, 0x07, 0x08, 0x00, 0x0A, 0x00, 0x0E, 0x00, 0x01, 0x40, 0x01,
+		// Packet Receipt Times
+		0x03, 0x00, 0x00, 0x04, 0x05, 0x06, 0x07, 0x08, 0x00, 0x0A, 0x00, 0x0C,
+		0x11, 0x22, 0x33, 0x44, 0x00, 0x00, 0x00, 0x00,
+		// Statistics Summary
+		0x06, 0xE8, 0x00, 0x09, 0x05, 0x06, 0x07, 0x08, 0x00, 0x0A, 0x00, 0x0D,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x05, 0x00, 0x00, 0x00, 0x06,
+		60, 64, 62, 1,
+		// unknown
+		0x04, 0x00, 0x00, 0x02, 0, 0, 0, 9, 0, 0, 0, 1}
+
+	buf := x.Marshal()
+	if !reflect.DeepEqual(buf, want) {
+		t.Fatalf("%s, buf:%x != want:%x", t.Name(), buf, want)
+	}
+
+	got, err := ParseExtendedReport(buf)
+	if err != nil {
+		t.Fatalf("%s, ParseExtendedReport err:%v", t.Name(), err)
+	}
+	if !reflect.DeepEqual(got, x) {
+		t.Fatalf("%s, round trip got:%+v != x:%+v", t.Name(), got, x)
+	}
+
+	// with thinning of 1, only 10 and 12 are included
+	if v := got.Blocks[1].(*RLEBlock).Values(); !reflect.DeepEqual(v, bits("01")) {
+		t.Fatalf("%s, Values():%v", t.Name(), v)
+	}
+
+	type test struct {
+		name string
+		buf  []byte
+		err  error
+	}
+
+	tests := []test{
+		{"rr", []byte{0x80, 0xC9, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01}, ErrType},
+		{"short", []byte{0x80, 0xCF, 0x00, 0x00}, ErrLength},
+		// the block length is past the end of the packet
+		{"block length", []byte{0x80, 0xCF, 0x00, 0x02, 0, 0, 0, 1, 0x01, 0x00, 0x00, 0x02}, ErrLength},
+		{"summary short", []byte{0x80, 0xCF, 0x00, 0x02, 0, 0, 0, 1, 0x06, 0x00, 0x00, 0x00}, ErrLength},
+	}
+
+	for i, tc := range tests {
+		_, err := ParseExtendedReport(tc.buf)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package rtcp
+
+// XRRecorder records the arrivals for the XR report blocks
+//
+// The Tracker classifies each arrival, so the recorder only needs to keep
+// the state of each sequence number in the current interval, which starts
+// at the first sequence number after the previous report.  Packets in the
+// safety buffers are ignored, and a restart of the window starts the
+// interval again.
+//
+// The receipt times are the arrival times in RTP timestamp units, relative
+// to the RTP timestamp of the first packet, and the jitter is the
+// difference in the transit times of consecutive arrivals, which is the
+// RFC 3550 D(i-1,i).
+
+import (
+	"math"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+const (
+	// XRMaxSpanCst is the most sequence numbers in an interval, as
+	// packets further ahead could be mistaken for packets behind
+	XRMaxSpanCst = 0x8000
+)
+
+// XRConfig is the source being reported on
+type XRConfig struct {
+	SSRC      uint32 // source being reported on
+	ClockRate uint32 // RTP timestamp units per second
+	ToH       uint8  // ToHNone, ToHIPv4, or ToHIPv6, for the TTL, or Hop Limit, statistics
+}
+
+// xrPacket is the state of a sequence number
+type xrPacket struct {
+	received   bool
+	duplicates uint32
+	receipt    uint32
+}
+
+// xrSummary is the running minimum, maximum, sum, and sum of the squares
+type xrSummary struct {
+	n     uint64
+	min   uint64
+	max   uint64
+	sum   float64
+	sumSq float64
+}
+
+func (s *xrSummary) add(v uint64) {
+	if s.n == 0 || v < s.min {
+		s.min = v
+	}
+	if v > s.max {
+		s.max = v
+	}
+	s.n++
+	s.sum += float64(v)
+	s.sumSq += float64(v) * float64(v)
+}
+
+// meanDev returns the mean, and the standard deviation
+func (s *xrSummary) meanDev() (mean uint64, dev uint64) {
+	if s.n == 0 {
+		return 0, 0
+	}
+	m := s.sum / float64(s.n)
+	v := s.sumSq/float64(s.n) - m*m
+	if v < 0 {
+		v = 0
+	}
+	return uint64(math.Round(m)), uint64(math.Round(math.Sqrt(v)))
+}
+
+// XRRecorder is the XR state for a single source
+type XRRecorder struct {
+	config XRConfig
+
+	started bool
+	begin   uint16
+	packets []xrPacket
+
+	// receipt time base
+	base   time.Time
+	baseTS uint32
+
+	transit     uint32
+	haveTransit bool
+	jitter      xrSummary
+	ttl         xrSummary
+}
+
+// NewXRRecorder creates an XRRecorder for the source
+func NewXRRecorder(config XRConfig) *XRRecorder {
+	return &XRRecorder{
+		config: config,
+	}
+}
+
+// receipt converts the arrival time into RTP timestamp units
+// Seconds and nanoseconds are converted separately to avoid overflow
+func (r *XRRecorder) receipt(arrival time.Time) uint32 {
+
+	d := arrival.Sub(r.base)
+	if d < 0 {
+		d = 0
+	}
+	sec := uint64(d / time.Second)
+	nanos := uint64(d % time.Second)
+	rate := uint64(r.config.ClockRate)
+
+	return r.baseTS + uint32(sec*rate+nanos*rate/uint64(time.Second))
+}
+
+// Packet records an arrival, using the Tracker classification
+// ttl is the IPv4 TTL, or IPv6 Hop Limit, and is ignored if ToH is ToHNone
+func (r *XRRecorder) Packet(tax *goTrackRTP.Taxonomy, seq uint16, rtpTS uint32, arrival time.Time, ttl uint8) {
+
+	if tax.Categroy == goTrackRTP.CategoryBuffer {
+		return
+	}
+
+	if !r.started || tax.Position == goTrackRTP.PositionInit || tax.Categroy == goTrackRTP.CategoryRestart {
+		r.reset(seq)
+		r.haveTransit = false
+		if r.base.IsZero() {
+			r.base = arrival
+			r.baseTS = rtpTS
+		}
+	}
+
+	off := int(seq - r.begin)
+	if off >= XRMaxSpanCst {
+		// behind the interval, or too far ahead
+		return
+	}
+	for len(r.packets) <= off {
+		r.packets = append(r.packets, xrPacket{})
+	}
+
+	p := &r.packets[off]
+	if p.received {
+		p.duplicates++
+		return
+	}
+	p.received = true
+	p.receipt = r.receipt(arrival)
+
+	transit := p.receipt - rtpTS
+	if r.haveTransit {
+		d := int32(transit - r.transit)
+		if d < 0 {
+			d = -d
+		}
+		r.jitter.add(uint64(d))
+	}
+	r.transit = transit
+	r.haveTransit = true
+
+	if r.config.ToH != ToHNone {
+		r.ttl.add(uint64(ttl))
+	}
+}
+
+// reset starts the interval at seq
+func (r *XRRecorder) reset(seq uint16) {
+	r.started = true
+	r.begin = seq
+	r.packets = r.packets[:0]
+	r.jitter = xrSummary{}
+	r.ttl = xrSummary{}
+}
+
+// Blocks returns the Loss RLE, Duplicate RLE, Packet Receipt Times, and
+// Statistics Summary blocks for the interval, and starts the next interval
+// Returns nil if nothing has arrived since the previous report
+func (r *XRRecorder) Blocks() []XRBlock {
+
+	if len(r.packets) == 0 {
+		return nil
+	}
+
+	begin := r.begin
+	end := begin + uint16(len(r.packets))
+
+	received := make([]bool, len(r.packets))
+	duplicated := make([]bool, len(r.packets))
+	times := make([]uint32, len(r.packets))
+
+	summary := &StatisticsSummaryBlock{
+		LossReport:      true,
+		DuplicateReport: true,
+		JitterReport:    r.jitter.n > 0,
+		ToH:             r.config.ToH,
+		SSRC:            r.config.SSRC,
+		BeginSeq:        begin,
+		EndSeq:          end,
+	}
+
+	for i, p := range r.packets {
+		received[i] = p.received
+		duplicated[i] = p.duplicates > 0
+		times[i] = p.receipt
+		if !p.received {
+			summary.Lost++
+		}
+		summary.Duplicates += p.duplicates
+	}
+
+	if r.jitter.n > 0 {
+		mean, dev := r.jitter.meanDev()
+		summary.MinJitter = uint32(r.jitter.min)
+		summary.MaxJitter = uint32(r.jitter.max)
+		summary.MeanJitter = uint32(mean)
+		summary.DevJitter = uint32(dev)
+	}
+	if r.ttl.n > 0 {
+		mean, dev := r.ttl.meanDev()
+		summary.MinTTL = uint8(r.ttl.min)
+		summary.MaxTTL = uint8(r.ttl.max)
+		summary.MeanTTL = uint8(mean)
+		summary.DevTTL = uint8(dev)
+	}
+
+	blocks := []XRBlock{
+		&RLEBlock{Type: BlockLossRLE, SSRC: r.config.SSRC, BeginSeq: begin, EndSeq: end, Chunks: EncodeChunks(received)},
+		&RLEBlock{Type: BlockDuplicateRLE, SSRC: r.config.SSRC, BeginSeq: begin, EndSeq: end, Chunks: EncodeChunks(duplicated)},
+		&ReceiptTimesBlock{SSRC: r.config.SSRC, BeginSeq: begin, EndSeq: end, Times: times},
+		summary,
+	}
+
+	r.reset(end)
+
+	return blocks
+}
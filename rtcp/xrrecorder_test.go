@@ -0,0 +1,93 @@
+package rtcp
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP"
+)
+
+func TestXRRecorder(t *testing.T) {
+
+	tr, err := goTrackRTP.New(10, 10, 10, 10, 0)
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+
+	// 1000 Hz, so the timestamp units are milliseconds
+	r := NewXRRecorder(XRConfig{SSRC: 1234, ClockRate: 1000, ToH: ToHIPv4})
+
+	type arrival struct {
+		seq   uint16
+		rtpTS uint32
+		ms    int
+		ttl   uint8
+	}
+
+	start := time.Unix(1700000000, 0)
+	arrive := func(as []arrival) {
+		for _, a := range as {
+			tax, e := tr.PacketArrival(a.seq)
+			if e != nil {
+				t.Fatalf("%s, PacketArrival err:%v", t.Name(), e)
+			}
+			r.Packet(tax, a.seq, a.rtpTS, start.Add(time.Duration(a.ms)*time.Millisecond), a.ttl)
+		}
+	}
+
+	if blocks := r.Blocks(); blocks != nil {
+		t.Fatalf("%s, Blocks():%v != nil before any packets", t.Name(), blocks)
+	}
+
+	// 2 is lost, 1 is duplicated, 3 is 5ms late, and 18 is in the ahead buffer
+	arrive([]arrival{{0, 0, 0, 64}, {1, 20, 20, 64}, {3, 60, 65, 63}, {1, 20, 70, 64}, {4, 80, 80, 62}, {18, 360, 90, 62}})
+
+	want := []XRBlock{
+		&RLEBlock{Type: BlockLossRLE, SSRC: 1234, BeginSeq: 0, EndSeq: 5, Chunks: []Chunk{0xEC00}},
+		&RLEBlock{Type: BlockDuplicateRLE, SSRC: 1234, BeginSeq: 0, EndSeq: 5, Chunks: []Chunk{0xA000}},
+		&ReceiptTimesBlock{SSRC: 1234, BeginSeq: 0, EndSeq: 5, Times: []uint32{0, 20, 0, 65, 80}},
+		&StatisticsSummaryBlock{LossReport: true, DuplicateReport: true, JitterReport: true, ToH: ToHIPv4,
+			SSRC: 1234, BeginSeq: 0, EndSeq: 5, Lost: 1, Duplicates: 1,
+			MinJitter: 0, MaxJitter: 5, MeanJitter: 3, DevJitter: 2,
+			MinTTL: 62, MaxTTL: 64, MeanTTL: 63, DevTTL: 1},
+	}
+
+	blocks := r.Blocks()
+	if !reflect.DeepEqual(blocks, want) {
+		t.Fatalf("%s, Blocks():%+v != want:%+v", t.Name(), blocks, want)
+	}
+
+	// 2 is now too late for the next interval
+	arrive([]arrival{{2, 40, 95, 64}, {5, 100, 100, 64}})
+
+	blocks = r.Blocks()
+	if len(blocks) != 4 {
+		t.Fatalf("%s, len(Blocks()):%d != 4", t.Name(), len(blocks))
+	}
+	loss := blocks[0].(*RLEBlock)
+	if loss.BeginSeq != 5 || loss.EndSeq != 6 || !reflect.DeepEqual(loss.Chunks, []Chunk{0x4001}) {
+		t.Fatalf("%s, second interval loss:%+v", t.Name(), loss)
+	}
+
+	// a restart starts the interval again
+	arrive([]arrival{{6, 120, 120, 64}, {30000, 5000, 140, 64}, {30001, 5020, 160, 64}})
+
+	blocks = r.Blocks()
+	summary := blocks[3].(*StatisticsSummaryBlock)
+	if summary.BeginSeq != 30000 || summary.EndSeq != 30002 || summary.Lost != 0 {
+		t.Fatalf("%s, restart summary:%+v", t.Name(), summary)
+	}
+
+	// and the XR packet round trips
+	x := ExtendedReport{SSRC: 1, Blocks: blocks}
+	got, err := ParseExtendedReport(x.Marshal())
+	if err != nil {
+		t.Fatalf("%s, ParseExtendedReport err:%v", t.Name(), err)
+	}
+	if !reflect.DeepEqual(got, x) {
+		t.Fatalf("%s, round trip got:%+v != x:%+v", t.Name(), got, x)
+	}
+}
@@ -0,0 +1,295 @@
+package rtp
+
+// rtp is a zero copy RTP header parser
+//
+// The parsed Packet holds slices of the original buffer, so the buffer
+// must not be modified while the Packet is in use.
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// https://www.rfc-editor.org/rfc/rfc3550#section-5.1
+// https://www.rfc-editor.org/rfc/rfc8285
+
+//     0                   1                   2                   3
+//     0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |V=2|P|X|  CC   |M|     PT      |       sequence number         |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |                           timestamp                           |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//    |           synchronization source (SSRC) identifier            |
+//    +=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+//    |            contributing source (CSRC) identifiers             |
+//    |                             ....                              |
+//    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	VersionCst = 2
+
+	HeaderLenCst          = 12
+	CSRCLenCst            = 4
+	ExtensionHeaderLenCst = 4
+
+	// RFC 8285 header extension profiles
+	ExtensionProfileOneByteCst     = 0xBEDE
+	ExtensionProfileTwoByteCst     = 0x1000
+	ExtensionProfileTwoByteMaskCst = 0xFFF0
+
+	extensionOneByteStopID = 15
+)
+
+var (
+	ErrHeaderShort         = errors.New("ErrHeaderShort packet shorter than the fixed header")
+	ErrVersion             = errors.New("ErrVersion unsupported RTP version")
+	ErrCSRCShort           = errors.New("ErrCSRCShort packet shorter than the CSRC list")
+	ErrExtensionShort      = errors.New("ErrExtensionShort packet shorter than the header extension")
+	ErrPadding             = errors.New("ErrPadding invalid padding length")
+	ErrExtensionElement    = errors.New("ErrExtensionElement header extension element overruns the extension")
+	ErrExtensionNotRFC8285 = errors.New("ErrExtensionNotRFC8285 header extension profile is not RFC 8285")
+)
+
+// ParseError describes a malformed packet
+// Use errors.Is to match the underlying Err
+type ParseError struct {
+	Err    error
+	Offset int // offset in the buffer where the problem was found
+	Len    int // length of the buffer
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("rtp: %v, offset:%d, len:%d", e.Err, e.Offset, e.Len)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Packet is a parsed RTP packet
+// CSRCs, ExtensionPayload and Payload are slices of the parsed buffer
+type Packet struct {
+	Version        uint8
+	Padding        bool
+	Extension      bool
+	CSRCCount      uint8
+	Marker         bool
+	PayloadType    uint8
+	SequenceNumber uint16
+	Timestamp      uint32
+	SSRC           uint32
+
+	CSRCs []byte // CSRCCount * 4 bytes, use CSRC(i)
+
+	ExtensionProfile uint16
+	ExtensionPayload []byte // the header extension, excluding profile and length
+
+	Payload     []byte // payload, excluding the padding
+	PaddingSize uint8
+}
+
+// Parse parses the RTP packet in buf
+// Parse allocates a Packet, see also (*Packet).Parse
+func Parse(buf []byte) (*Packet, error) {
+
+	p := &Packet{}
+
+	err := p.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Parse parses the RTP packet in buf into p, without copying or allocating
+func (p *Packet) Parse(buf []byte) error {
+
+	if len(buf) < HeaderLenCst {
+		return &ParseError{Err: ErrHeaderShort, Offset: 0, Len: len(buf)}
+	}
+
+	p.Version = buf[0] >> 6
+	if p.Version != VersionCst {
+		return &ParseError{Err: ErrVersion, Offset: 0, Len: len(buf)}
+	}
+
+	p.Padding = buf[0]&0x20 != 0
+	p.Extension = buf[0]&0x10 != 0
+	p.CSRCCount = buf[0] & 0x0F
+	p.Marker = buf[1]&0x80 != 0
+	p.PayloadType = buf[1] & 0x7F
+	p.SequenceNumber = binary.BigEndian.Uint16(buf[2:4])
+	p.Timestamp = binary.BigEndian.Uint32(buf[4:8])
+	p.SSRC = binary.BigEndian.Uint32(buf[8:12])
+
+	offset := HeaderLenCst
+
+	csrcEnd := offset + int(p.CSRCCount)*CSRCLenCst
+	if len(buf) < csrcEnd {
+		return &ParseError{Err: ErrCSRCShort, Offset: offset, Len: len(buf)}
+	}
+	p.CSRCs = buf[offset:csrcEnd]
+	offset = csrcEnd
+
+	p.ExtensionProfile = 0
+	p.ExtensionPayload = nil
+	if p.Extension {
+		if len(buf) < offset+ExtensionHeaderLenCst {
+			return &ParseError{Err: ErrExtensionShort, Offset: offset, Len: len(buf)}
+		}
+		p.ExtensionProfile = binary.BigEndian.Uint16(buf[offset : offset+2])
+		extLen := int(binary.BigEndian.Uint16(buf[offset+2:offset+4])) * 4
+		offset += ExtensionHeaderLenCst
+
+		if len(buf) < offset+extLen {
+			return &ParseError{Err: ErrExtensionShort, Offset: offset, Len: len(buf)}
+		}
+		p.ExtensionPayload = buf[offset : offset+extLen]
+		offset += extLen
+	}
+
+	end := len(buf)
+	p.PaddingSize = 0
+	if p.Padding {
+		if end <= offset {
+			return &ParseError{Err: ErrPadding, Offset: end - 1, Len: len(buf)}
+		}
+		p.PaddingSize = buf[end-1]
+		if p.PaddingSize == 0 || int(p.PaddingSize) > end-offset {
+			return &ParseError{Err: ErrPadding, Offset: end - 1, Len: len(buf)}
+		}
+		end -= int(p.PaddingSize)
+	}
+	p.Payload = buf[offset:end]
+
+	return nil
+}
+
+// CSRC returns the i-th contributing source identifier
+func (p *Packet) CSRC(i int) uint32 {
+	return binary.BigEndian.Uint32(p.CSRCs[i*CSRCLenCst : (i+1)*CSRCLenCst])
+}
+
+// IsOneByteExtension returns true for the RFC 8285 one-byte header extension
+func (p *Packet) IsOneByteExtension() bool {
+	return p.Extension && p.ExtensionProfile == ExtensionProfileOneByteCst
+}
+
+// IsTwoByteExtension returns true for the RFC 8285 two-byte header extension
+func (p *Packet) IsTwoByteExtension() bool {
+	return p.Extension && p.ExtensionProfile&ExtensionProfileTwoByteMaskCst == ExtensionProfileTwoByteCst
+}
+
+// Extensions iterates over the RFC 8285 header extension elements, calling
+// f with each element id and data, until f returns false
+// The data is a slice of the parsed buffer
+// Errors are a *ParseError, with the Offset in the parsed buffer
+// https://www.rfc-editor.org/rfc/rfc8285#section-4
+func (p *Packet) Extensions(f func(id uint8, data []byte) bool) error {
+
+	if !p.Extension {
+		return nil
+	}
+
+	switch {
+	case p.IsOneByteExtension():
+		return p.extensionsOneByte(f)
+	case p.IsTwoByteExtension():
+		return p.extensionsTwoByte(f)
+	}
+
+	// the offset of the extension profile
+	return p.extensionError(ErrExtensionNotRFC8285, -ExtensionHeaderLenCst)
+}
+
+// extensionError returns a ParseError for the offset in the ExtensionPayload
+// The Packet doesn't keep the buffer, so the buffer offset and length are
+// recalculated from the parsed fields
+func (p *Packet) extensionError(err error, i int) error {
+
+	start := HeaderLenCst + int(p.CSRCCount)*CSRCLenCst + ExtensionHeaderLenCst
+	l := start + len(p.ExtensionPayload) + len(p.Payload) + int(p.PaddingSize)
+
+	return &ParseError{Err: err, Offset: start + i, Len: l}
+}
+
+// extensionsOneByte walks the one-byte header elements
+// https://www.rfc-editor.org/rfc/rfc8285#section-4.2
+func (p *Packet) extensionsOneByte(f func(id uint8, data []byte) bool) error {
+
+	b := p.ExtensionPayload
+	for i := 0; i < len(b); {
+		id := b[i] >> 4
+		if id == 0 {
+			// padding
+			i++
+			continue
+		}
+		if id == extensionOneByteStopID {
+			return nil
+		}
+		l := int(b[i]&0x0F) + 1
+		if i+1+l > len(b) {
+			return p.extensionError(ErrExtensionElement, i)
+		}
+		i++
+		if !f(id, b[i:i+l]) {
+			return nil
+		}
+		i += l
+	}
+
+	return nil
+}
+
+// extensionsTwoByte walks the two-byte header elements
+// https://www.rfc-editor.org/rfc/rfc8285#section-4.3
+func (p *Packet) extensionsTwoByte(f func(id uint8, data []byte) bool) error {
+
+	b := p.ExtensionPayload
+	for i := 0; i < len(b); {
+		id := b[i]
+		if id == 0 {
+			// padding
+			i++
+			continue
+		}
+		if i+1 >= len(b) {
+			return p.extensionError(ErrExtensionElement, i)
+		}
+		l := int(b[i+1])
+		if i+2+l > len(b) {
+			return p.extensionError(ErrExtensionElement, i)
+		}
+		i += 2
+		if !f(id, b[i:i+l]) {
+			return nil
+		}
+		i += l
+	}
+
+	return nil
+}
+
+// ExtensionElement returns the data of the RFC 8285 header extension element with the id
+func (p *Packet) ExtensionElement(id uint8) (data []byte, ok bool) {
+
+	err := p.Extensions(func(i uint8, d []byte) bool {
+		if i == id {
+			data = d
+			ok = true
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, false
+	}
+
+	return data, ok
+}
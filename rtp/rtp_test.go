@@ -0,0 +1,210 @@
+package rtp
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	type test struct {
+		name           string
+		buf            []byte
+		err            error
+		Marker         bool
+		PayloadType    uint8
+		SequenceNumber uint16
+		Timestamp      uint32
+		SSRC           uint32
+		CSRCs          []uint32
+		Payload        []byte
+		PaddingSize    uint8
+	}
+
+	tests := []test{
+		{"basic",
+			[]byte{0x80, 0x21, 0x12, 0x34, 0x00, 0x00, 0x00, 0x01, 0xDE, 0xAD, 0xBE, 0xEF, 0x01, 0x02},
+			nil, false, 33, 0x1234, 1, 0xDEADBEEF, nil, []byte{0x01, 0x02}, 0},
+		{"marker",
+			[]byte{0x80, 0xE0, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0x02},
+			nil, true, 96, 0xFFFF, 0xFFFFFFFF, 2, nil, []byte{}, 0},
+		{"csrc",
+			[]byte{0x82, 0x21, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03,
+				0x00, 0x00, 0x00, 0x0A, 0x00, 0x00, 0x00, 0x0B, 0x01},
+			nil, false, 33, 1, 1, 3, []uint32{10, 11}, []byte{0x01}, 0},
+		{"padding",
+			[]byte{0xA0, 0x21, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03,
+				0x01, 0x02, 0x00, 0x00, 0x03},
+			nil, false, 33, 1, 1, 3, nil, []byte{0x01, 0x02}, 3},
+		{"extension",
+			[]byte{0x90, 0x21, 0x00, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03,
+				0xBE, 0xDE, 0x00, 0x01, 0x10, 0xAA, 0x00, 0x00, 0x01},
+			nil, false, 33, 5, 1, 3, nil, []byte{0x01}, 0},
+		// errors
+		{"short", []byte{0x80, 0x21, 0x12, 0x34}, ErrHeaderShort, false, 0, 0, 0, 0, nil, nil, 0},
+		{"version",
+			[]byte{0x40, 0x21, 0x12, 0x34, 0x00, 0x00, 0x00, 0x01, 0xDE, 0xAD, 0xBE, 0xEF},
+			ErrVersion, false, 0, 0, 0, 0, nil, nil, 0},
+		{"csrc short",
+			[]byte{0x82, 0x21, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x0A},
+			ErrCSRCShort, false, 0, 0, 0, 0, nil, nil, 0},
+		{"extension short",
+			[]byte{0x90, 0x21, 0x00, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 0xBE, 0xDE, 0x00, 0x02, 0x10, 0xAA, 0x00, 0x00},
+			ErrExtensionShort, false, 0, 0, 0, 0, nil, nil, 0},
+		{"padding zero",
+			[]byte{0xA0, 0x21, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 0x01, 0x00},
+			ErrPadding, false, 0, 0, 0, 0, nil, nil, 0},
+		{"padding too long",
+			[]byte{0xA0, 0x21, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03, 0x01, 0x05},
+			ErrPadding, false, 0, 0, 0, 0, nil, nil, 0},
+	}
+
+	for i, tc := range tests {
+
+		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc.name)
+
+		p, err := Parse(tc.buf)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s, err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+		if err != nil {
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("%s, test:%d %s, err:%v is not a *ParseError", t.Name(), i, tc.name, err)
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(p.Marker, tc.Marker) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(p.Marker:%v, tc.Marker:%v)", t.Name(), i, p.Marker, tc.Marker)
+		}
+		if !reflect.DeepEqual(p.PayloadType, tc.PayloadType) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(p.PayloadType:%v, tc.PayloadType:%v)", t.Name(), i, p.PayloadType, tc.PayloadType)
+		}
+		if !reflect.DeepEqual(p.SequenceNumber, tc.SequenceNumber) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(p.SequenceNumber:%v, tc.SequenceNumber:%v)", t.Name(), i, p.SequenceNumber, tc.SequenceNumber)
+		}
+		if !reflect.DeepEqual(p.Timestamp, tc.Timestamp) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(p.Timestamp:%v, tc.Timestamp:%v)", t.Name(), i, p.Timestamp, tc.Timestamp)
+		}
+		if !reflect.DeepEqual(p.SSRC, tc.SSRC) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(p.SSRC:%v, tc.SSRC:%v)", t.Name(), i, p.SSRC, tc.SSRC)
+		}
+
+		var csrcs []uint32
+		for j := 0; j < int(p.CSRCCount); j++ {
+			csrcs = append(csrcs, p.CSRC(j))
+		}
+		if !reflect.DeepEqual(csrcs, tc.CSRCs) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(csrcs:%v, tc.CSRCs:%v)", t.Name(), i, csrcs, tc.CSRCs)
+		}
+		if !reflect.DeepEqual(p.Payload, tc.Payload) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(p.Payload:%v, tc.Payload:%v)", t.Name(), i, p.Payload, tc.Payload)
+		}
+		if !reflect.DeepEqual(p.PaddingSize, tc.PaddingSize) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(p.PaddingSize:%v, tc.PaddingSize:%v)", t.Name(), i, p.PaddingSize, tc.PaddingSize)
+		}
+	}
+}
+
+func TestExtensions(t *testing.T) {
+
+	type ext struct {
+		id   uint8
+		data []byte
+	}
+
+	type test struct {
+		name   string
+		buf    []byte
+		err    error
+		offset int // of the ParseError
+		exts   []ext
+	}
+
+	header := []byte{0x90, 0x21, 0x00, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03}
+
+	tests := []test{
+		// one-byte: id:1 len:1, padding, id:2 len:2
+		{"one-byte",
+			append(append([]byte{}, header...), 0xBE, 0xDE, 0x00, 0x02, 0x10, 0xAA, 0x00, 0x21, 0xBB, 0xCC, 0x00, 0x00),
+			nil, 0, []ext{{1, []byte{0xAA}}, {2, []byte{0xBB, 0xCC}}}},
+		// one-byte: id:15 stops processing
+		{"one-byte stop",
+			append(append([]byte{}, header...), 0xBE, 0xDE, 0x00, 0x01, 0x10, 0xAA, 0xF0, 0x00),
+			nil, 0, []ext{{1, []byte{0xAA}}}},
+		// two-byte: id:1 len:0, id:2 len:3
+		{"two-byte",
+			append(append([]byte{}, header...), 0x10, 0x00, 0x00, 0x02, 0x01, 0x00, 0x02, 0x03, 0xAA, 0xBB, 0xCC, 0x00),
+			nil, 0, []ext{{1, []byte{}}, {2, []byte{0xAA, 0xBB, 0xCC}}}},
+		// errors
+		{"one-byte overrun",
+			append(append([]byte{}, header...), 0xBE, 0xDE, 0x00, 0x01, 0x1F, 0xAA, 0x00, 0x00),
+			ErrExtensionElement, 16, []ext{}},
+		// two-byte: id:1 len:5
+		{"two-byte overrun",
+			append(append([]byte{}, header...), 0x10, 0x00, 0x00, 0x01, 0x01, 0x05, 0xAA, 0x00),
+			ErrExtensionElement, 16, []ext{}},
+		// two-byte: padding, then id:1 without the length
+		{"two-byte short",
+			append(append([]byte{}, header...), 0x10, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01),
+			ErrExtensionElement, 19, []ext{}},
+		{"not rfc8285",
+			append(append([]byte{}, header...), 0x12, 0x34, 0x00, 0x01, 0x10, 0xAA, 0x00, 0x00),
+			ErrExtensionNotRFC8285, 12, []ext{}},
+	}
+
+	for i, tc := range tests {
+
+		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc.name)
+
+		p, err := Parse(tc.buf)
+		if err != nil {
+			t.Fatalf("%s, test:%d %s, err:%v", t.Name(), i, tc.name, err)
+		}
+
+		exts := []ext{}
+		err = p.Extensions(func(id uint8, data []byte) bool {
+			exts = append(exts, ext{id, data})
+			return true
+		})
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s, err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+		if err != nil {
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Offset != tc.offset || pe.Len != len(tc.buf) {
+				t.Fatalf("%s, test:%d %s, err:%v offset != tc.offset:%d, len != %d", t.Name(), i, tc.name, err, tc.offset, len(tc.buf))
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(exts, tc.exts) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(exts:%v, tc.exts:%v)", t.Name(), i, exts, tc.exts)
+		}
+
+		for _, e := range tc.exts {
+			data, ok := p.ExtensionElement(e.id)
+			if !ok || !reflect.DeepEqual(data, e.data) {
+				t.Fatalf("%s, test:%d ExtensionElement(%d):%v, ok:%v != %v", t.Name(), i, e.id, data, ok, e.data)
+			}
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	buf := []byte{0x90, 0x21, 0x00, 0x05, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x03,
+		0xBE, 0xDE, 0x00, 0x01, 0x10, 0xAA, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04}
+	var p Packet
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := p.Parse(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -13,14 +13,18 @@ package goTrackRTP
 
 import (
 	"errors"
-	"log"
-
-	"github.com/google/btree"
+	"fmt"
+	"log/slog"
+	"time"
 )
 
 const (
 	BtreeDegreeCst = 3
 
+	// LateThresholdCst is the default number of packets behind Max() a packet
+	// can be and still be considered reordered, rather than late
+	LateThresholdCst = 3
+
 	ClearFreeListCst = true
 
 	maxUint16 = ^uint16(0)
@@ -32,7 +36,7 @@ var (
 )
 
 type Tracker struct {
-	b *btree.BTreeG[uint16]
+	b Storage // btree, or bitmap ring
 
 	aw uint16 // aheadWindow
 	bw uint16 // behindWindow
@@ -43,7 +47,21 @@ type Tracker struct {
 	bwPlusBb uint16 // bw + bb
 	Window   uint16 // aw + bw
 
-	debugLevel int
+	lt uint16 // lateThreshold
+
+	back uint16 // oldest sequence number tracked, used to count the losses falling off the back
+
+	stats  Stats
+	rfc    RFC3550
+	jitter Jitter
+	bursts Bursts
+
+	tw *timeWindows // nil for packet windows
+
+	callbacks Callbacks
+	evicted   []uint16 // reused by evictedLoss
+
+	logger *slog.Logger // discards, unless set, see trackRTP_log.go
 }
 
 type Taxonomy struct {
@@ -52,6 +70,7 @@ type Taxonomy struct {
 	SubCategory int
 	Len         int
 	Jump        uint16
+	Lost        uint16 // never received sequence numbers, which fell off the back of the window
 }
 
 // Position
@@ -78,6 +97,15 @@ const (
 	SubCategoryDuplicate
 	SubCategoryAlready
 	SubCategoryJump
+	SubCategoryLate
+	SubCategoryReordered
+)
+
+// Counts, for sizing arrays indexed by Position, Category, and SubCategory
+const (
+	PositionCount    = PositionDuplicate + 1
+	CategoryCount    = CategoryWindow + 1
+	SubCategoryCount = SubCategoryReordered + 1
 )
 
 type TrackIntToStringMap struct {
@@ -91,6 +119,7 @@ func NewMaps() *TrackIntToStringMap {
 	pm := make(map[int]string)
 	pm[PositionUnknown] = "Unknown"
 	pm[PositionInit] = "Init"
+	pm[PositionAhead] = "Ahead"
 	pm[PositionBehind] = "Behind"
 	pm[PositionDuplicate] = "Duplicate"
 
@@ -106,6 +135,8 @@ func NewMaps() *TrackIntToStringMap {
 	sm[SubCategoryDuplicate] = "Duplicate"
 	sm[SubCategoryAlready] = "Already"
 	sm[SubCategoryJump] = "Jump"
+	sm[SubCategoryLate] = "Late"
+	sm[SubCategoryReordered] = "Reordered"
 
 	return &TrackIntToStringMap{
 		PosMap:    pm,
@@ -129,14 +160,32 @@ func New(aw uint16, bw uint16, ab uint16, bb uint16, debugLevel int) (*Tracker,
 // See also "degree" or branching factor: https://en.wikipedia.org/wiki/Branching_factor
 func NewDegree(aw uint16, bw uint16, ab uint16, bb uint16, degree int, debugLevel int) (*Tracker, error) {
 
-	err := validateNew(aw, bw, ab, bb, degree)
+	return newTracker(aw, bw, ab, bb, StorageBTree, degree, debugLevel)
+}
+
+// NewBitmap creates a Tracker using the bitmap ring storage, rather than the B-tree
+func NewBitmap(aw uint16, bw uint16, ab uint16, bb uint16, debugLevel int) (*Tracker, error) {
+
+	return newTracker(aw, bw, ab, bb, StorageBitmap, BtreeDegreeCst, debugLevel)
+}
+
+// NewWithStorage creates a Tracker using the selected storage
+// storage = StorageBTree, or StorageBitmap
+func NewWithStorage(aw uint16, bw uint16, ab uint16, bb uint16, storage int, debugLevel int) (*Tracker, error) {
+
+	return newTracker(aw, bw, ab, bb, storage, BtreeDegreeCst, debugLevel)
+}
+
+// newTracker validates, and then creates the Tracker
+func newTracker(aw uint16, bw uint16, ab uint16, bb uint16, storage int, degree int, debugLevel int) (*Tracker, error) {
+
+	err := errors.Join(validateNew(aw, bw, ab, bb, degree), validateStorage(storage))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Tracker{
-		b: btree.NewG[uint16](degree, isLess),
-		//b:        btree.NewOrderedG[uint16](degree),
+		b:        newStorage(storage, degree),
 		aw:       aw,
 		bw:       bw,
 		ab:       ab,
@@ -144,26 +193,79 @@ func NewDegree(aw uint16, bw uint16, ab uint16, bb uint16, degree int, debugLeve
 		awPlusAb: aw + ab,
 		bwPlusBb: bw + bb,
 		Window:   aw + bw,
+		lt:       LateThresholdCst,
 
-		debugLevel: debugLevel,
+		logger: newLogger(debugLevel),
 	}, nil
 }
 
+// SetLateThreshold sets the number of packets behind Max() a packet can
+// arrive and still be classified as SubCategoryReordered.  Packets further
+// behind, but still within the behind window, are SubCategoryLate.
+func (t *Tracker) SetLateThreshold(lt uint16) error {
+
+	err := validateLateThreshold(lt, t.bw)
+	if err != nil {
+		return err
+	}
+
+	t.lt = lt
+
+	return nil
+}
+
 // PacketArrival is the primary packet handling entry point
+// Trackers with time windows use time.Now() as the arrival time
 func (t *Tracker) PacketArrival(seq uint16) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		log.Printf("PacketArrival, seq:%d", seq)
+	var now time.Time
+	if t.tw != nil {
+		now = time.Now()
 	}
 
+	return t.PacketArrivalAt(seq, now)
+}
+
+// PacketArrivalAt is PacketArrival with the arrival time, which is only
+// used by Trackers with time windows
+func (t *Tracker) PacketArrivalAt(seq uint16, now time.Time) (*Taxonomy, error) {
+
+	t.rfc.update(seq)
+
+	// Evict by time first, so the packet is classified against the window
+	// as of its arrival, rather than as of the previous packet
+	var lost uint16
+	if t.tw != nil {
+		lost = t.evictByTime(now)
+	}
+
+	tax, err := t.packetArrival(seq)
+	if err != nil {
+		return tax, err
+	}
+
+	if t.tw != nil {
+		tax.Lost += lost
+		t.timeArrival(seq, tax, now)
+	}
+
+	// after the time window eviction, so the Taxonomy is final
+	t.packetCallbacks(seq, tax)
+
+	t.stats.add(tax)
+
+	return tax, nil
+}
+
+// packetArrival classifies the packet and updates the btree
+func (t *Tracker) packetArrival(seq uint16) (*Taxonomy, error) {
+
 	m, ok := t.b.Max()
 	if !ok {
 		return t.init(seq)
 	}
 
-	if t.debugLevel > 10 {
-		log.Printf("PacketArrival, seq:%d, m:%d", seq, m)
-	}
+	t.debug("packetArrival", seqAttr(seq), maxAttr(m))
 
 	if seq == m {
 		return t.positionDuplicate(seq, m)
@@ -179,24 +281,17 @@ func (t *Tracker) PacketArrival(seq uint16) (*Taxonomy, error) {
 // init is initilizing the data structure on the first packet received
 func (t *Tracker) init(seq uint16) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		m, _ := t.b.Max()
-		log.Printf("init, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
-
 	tax := &Taxonomy{}
 	tax.Position = PositionInit
 
-	// https://pkg.go.dev/github.com/google/btree#BTree.ReplaceOrInsert
-	_, already := t.b.ReplaceOrInsert(seq)
+	t.back = seq
+
+	already := t.b.Insert(seq)
 	if already {
 		tax.SubCategory = SubCategoryAlready
 	}
 
-	if t.debugLevel > 10 {
-		m, _ := t.b.Max()
-		log.Printf("init, after insert seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
+	t.debug("init", seqAttr(seq), positionAttr(tax.Position), lenAttr(t.b.Len()))
 
 	tax.Len = t.b.Len()
 
@@ -206,9 +301,7 @@ func (t *Tracker) init(seq uint16) (*Taxonomy, error) {
 // positionDuplicate is seq == m
 func (t *Tracker) positionDuplicate(seq, m uint16) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		log.Printf("positionDuplicate, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
+	t.debug("positionDuplicate", seqAttr(seq), maxAttr(m), lenAttr(t.b.Len()))
 
 	tax := &Taxonomy{}
 	tax.Position = PositionDuplicate
@@ -221,15 +314,13 @@ func (t *Tracker) positionDuplicate(seq, m uint16) (*Taxonomy, error) {
 // positionAhead handles seq > Max()2
 func (t *Tracker) positionAhead(seq, m uint16) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		log.Printf("positionAhead, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
-
 	tax := &Taxonomy{}
 	tax.Position = PositionAhead
 
 	diff := uint16Diff(seq, m)
 
+	t.debug("positionAhead", seqAttr(seq), maxAttr(m), lenAttr(t.b.Len()), diffAttr(diff))
+
 	// m < aheadWindow [aw] < categoryBuffer (no op) [aheadBuffer ] < categoryRestart
 	if diff > t.awPlusAb {
 		return t.categoryRestart(seq, tax)
@@ -242,54 +333,48 @@ func (t *Tracker) positionAhead(seq, m uint16) (*Taxonomy, error) {
 // positionBehind handles seq < Max()
 func (t *Tracker) positionBehind(seq, m uint16) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		log.Printf("positionBehind, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
-
 	tax := &Taxonomy{}
 	tax.Position = PositionBehind
 
 	diff := uint16Diff(seq, m)
 
+	t.debug("positionBehind", seqAttr(seq), maxAttr(m), lenAttr(t.b.Len()), diffAttr(diff))
+
 	// m < behindWindow [bw] < categoryBuffer (no op) [behindBuffer ] < categoryRestart
 	if diff > t.bwPlusBb {
-
-		if t.debugLevel > 10 {
-			log.Printf("positionBehind, seq:%d, diff:%d > t.bwPlusBb:%d)", seq, diff, t.bwPlusBb)
-		}
-
 		return t.categoryRestart(seq, tax)
-
 	} else if diff > t.bw {
-
-		if t.debugLevel > 10 {
-			log.Printf("positionBehind, seq:%d, diff:%d > t.bw:%d", seq, diff, t.bw)
-		}
-
 		return t.categoryBuffer(seq, tax)
-	}
-
-	if t.debugLevel > 10 {
-		log.Println("positionBehind, in window")
+	} else if t.tooLate(seq) {
+		t.debug("positionBehind, older than the time window back", seqAttr(seq), minAttr(t.back))
+		return t.categoryBuffer(seq, tax)
 	}
 
 	return t.behindWindow(seq, m, diff, tax)
 }
 
 // categoryRestart clears the btree and inserts the new seq
-// See also: https://pkg.go.dev/github.com/google/btree#BTreeG.Clear
+// The sequence numbers missing from the old window will never be received,
+// so they are counted as lost
 func (t *Tracker) categoryRestart(seq uint16, tax *Taxonomy) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		m, _ := t.b.Max()
-		log.Printf("categoryRestart, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
-
 	tax.Categroy = CategoryRestart
 
-	t.b.Clear(ClearFreeListCst)
+	m, _ := t.b.Max()
+
+	t.debug("categoryRestart", seqAttr(seq), maxAttr(m), lenAttr(t.b.Len()),
+		positionAttr(tax.Position), categoryAttr(tax.Categroy))
+
+	tax.Lost = m - t.back + 1 - uint16(t.b.Len())
 
-	_, already := t.b.ReplaceOrInsert(seq)
+	t.walkBursts(m + 1)
+	t.bursts.end()
+	t.evictedLoss(m + 1)
+
+	t.b.Clear()
+	t.back = seq
+
+	already := t.b.Insert(seq)
 	if already {
 		tax.SubCategory = SubCategoryAlready
 	}
@@ -305,13 +390,14 @@ func (t *Tracker) categoryRestart(seq uint16, tax *Taxonomy) (*Taxonomy, error)
 // has different latency characteristics than you think?
 func (t *Tracker) categoryBuffer(seq uint16, tax *Taxonomy) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
+	tax.Categroy = CategoryBuffer
+
+	if t.debugEnabled() {
 		m, _ := t.b.Max()
-		log.Printf("categoryBuffer, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
+		t.debug("categoryBuffer", seqAttr(seq), maxAttr(m), lenAttr(t.b.Len()),
+			positionAttr(tax.Position), categoryAttr(tax.Categroy))
 	}
 
-	tax.Categroy = CategoryBuffer
-
 	tax.Len = t.b.Len()
 
 	return tax, nil
@@ -322,39 +408,27 @@ func (t *Tracker) categoryBuffer(seq uint16, tax *Taxonomy) (*Taxonomy, error) {
 // See also: https://pkg.go.dev/github.com/google/btree#BTreeG.DescendLessOrEqual
 func (t *Tracker) aheadWindow(seq, m uint16, diff uint16, tax *Taxonomy) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		log.Printf("aheadWindow, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
-
 	tax.Categroy = CategoryWindow
 	tax.Jump = diff
 
-	_, duplicate := t.b.ReplaceOrInsert(seq)
-	m, _ = t.b.Max()
+	duplicate := t.b.Insert(seq)
 	if duplicate {
 		tax.SubCategory = SubCategoryDuplicate
-		if t.debugLevel > 10 {
-			log.Printf("aheadWindow, DUPLICATE, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-		}
 	} else if diff == 1 {
+		// This is the best outcome! woot woot!
 		tax.SubCategory = SubCategoryNext
-		if t.debugLevel > 10 {
-			log.Printf("aheadWindow, diff==1. This is the best outcome! woot woot!")
-		}
 	} else {
 		tax.SubCategory = SubCategoryJump
-		if t.debugLevel > 10 {
-			log.Printf("aheadWindow, jump:%d", diff)
-		}
 	}
 
-	if t.debugLevel > 10 {
-		m, _ := t.b.Max()
+	if t.debugEnabled() {
+		m, _ = t.b.Max()
 		min, _ := t.b.Min()
-		log.Printf("aheadWindow inserted, seq:%d, t.b.Max():%d, t.b.Min():%d, t.b.Len():%d, diff:%d", seq, m, min, t.b.Len(), diff)
+		t.debug("aheadWindow", seqAttr(seq), maxAttr(m), minAttr(min), lenAttr(t.b.Len()), diffAttr(diff),
+			positionAttr(tax.Position), categoryAttr(tax.Categroy), subCategoryAttr(tax.SubCategory))
 	}
 
-	t.deleteItemsFallingOffTheBack(seq)
+	tax.Lost = t.deleteItemsFallingOffTheBack(seq)
 
 	tax.Len = t.b.Len()
 
@@ -363,88 +437,81 @@ func (t *Tracker) aheadWindow(seq, m uint16, diff uint16, tax *Taxonomy) (*Taxon
 
 // deleteItemsFallingOffTheBack is called by aheadWindow, and deletes
 // items falling off the back of the behindWindow
-func (t *Tracker) deleteItemsFallingOffTheBack(seq uint16) {
+// Returns the number of sequence numbers that fell off the back without
+// ever being received.  These are final losses, so are only counted once.
+func (t *Tracker) deleteItemsFallingOffTheBack(seq uint16) (lost uint16) {
+
+	return t.evictBefore(seq - t.aw - t.bw + 1)
+}
+
+// evictBefore deletes the items before backOfWindow, and returns the
+// number of sequence numbers that were never received
+func (t *Tracker) evictBefore(backOfWindow uint16) (lost uint16) {
 
 	min, ok := t.b.Min()
 	if !ok {
-		log.Panicf("aheadWindow Min() not ok:%v, min:%d", ok, min)
+		panic(fmt.Sprintf("evictBefore Min() not ok:%v, min:%d", ok, min))
 	}
 
-	backOfWindow := seq - t.aw - t.bw + 1
+	if isLess(t.back, backOfWindow) {
+		t.walkBursts(backOfWindow)
+		t.evictedLoss(backOfWindow)
+	}
+
+	var deleted int
 	if isLess(min, backOfWindow) {
 
-		// Iterate to clear the items which are falling off the back
-		// ( An alternative strategy would be to loop doing deleteMin,
-		// but that would be more calls to the btree. )
-
-		var deleted []uint16
-		//t.b.DescendLessOrEqual(backOfWindow, func(item uint16) bool {
-		t.b.Ascend(func(item uint16) bool {
-			if t.debugLevel > 10 {
-				log.Printf("aheadWindow, Ascend backOfWindow:%d, min:%d, item:%d", backOfWindow, min, item)
-			}
-			if isLess(item, backOfWindow) {
-				_, ok := t.b.Delete(item)
-				if !ok {
-					log.Panicf("aheadWindow DescendLessOrEqual Delete not ok:%v", item)
-				}
-				deleted = append(deleted, item)
-				if t.debugLevel > 10 {
-					log.Printf("aheadWindow, Ascend backOfWindow:%d, min:%d, deleted item:%d", backOfWindow, min, item)
-				}
-				return true
-			}
-			if t.debugLevel > 10 {
-				if !isLess(item, backOfWindow) {
-					log.Printf("aheadWindow, !isLess(item:%d, backOfWindow:%d)", item, backOfWindow)
-				}
-			}
-			return false
-		})
-
-		if t.debugLevel > 10 {
-			m, _ := t.b.Max()
-			log.Printf("aheadWindow deleted, seq:%d, t.b.Max():%d, t.b.Len():%d, len(deleted):%d, deleted:%v",
-				seq, m, t.b.Len(), len(deleted), deleted)
-		}
+		deleted = t.b.EvictBefore(backOfWindow)
+
+		t.debug("evictBefore deleted", slog.Int("back", int(backOfWindow)), minAttr(min), lenAttr(t.b.Len()),
+			slog.Int("deleted", deleted))
+	}
+
+	// All the items are >= t.back, so the items deleted were all
+	// within [t.back, backOfWindow), and the rest of that span was lost
+	if isLess(t.back, backOfWindow) {
+		lost = backOfWindow - t.back - uint16(deleted)
+		t.back = backOfWindow
+
+		t.debug("evictBefore lost", slog.Int("back", int(backOfWindow)), slog.Int("lost", int(lost)))
 	}
+
+	return lost
 }
 
 // behindWindow handles when the sequence number is within our current
 // lookback window
 func (t *Tracker) behindWindow(seq, m uint16, diff uint16, tax *Taxonomy) (*Taxonomy, error) {
 
-	if t.debugLevel > 10 {
-		log.Printf("behindWindow, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
-
 	tax.Categroy = CategoryWindow
 
-	_, duplicate := t.b.ReplaceOrInsert(seq)
-	m, _ = t.b.Max()
+	duplicate := t.b.Insert(seq)
+
+	// Before the first items fall off the back, a behind packet can be
+	// older than the first packet received, so extend the tracked range
+	if isLess(seq, t.back) {
+		t.back = seq
+	}
+
 	if duplicate {
 
 		tax.SubCategory = SubCategoryDuplicate
 
-		if t.debugLevel > 10 {
-			log.Printf("behindWindow, DUPLICATE, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-		}
+	} else if diff <= t.lt {
+
+		// Filled a hole just behind Max(), so only slightly out of order
+		tax.SubCategory = SubCategoryReordered
 
+	} else {
+
+		// Filled a hole further back, so the packet was recovered late
+		tax.SubCategory = SubCategoryLate
 	}
-	// We don't track "jump" behind
-	// else {
-	// 	tax.SubCategory = SubCategoryJump
-	// 	if t.debugLevel > 10 {
-	// 		log.Printf("behindWindow, jump:%d", diff)
-	// 	}
-	// }
 
 	tax.Jump = diff
 
-	if t.debugLevel > 10 {
-		m, _ := t.b.Max()
-		log.Printf("behindWindow inserted, seq:%d, t.b.Max():%d, t.b.Len():%d", seq, m, t.b.Len())
-	}
+	t.debug("behindWindow", seqAttr(seq), maxAttr(m), lenAttr(t.b.Len()), diffAttr(diff),
+		positionAttr(tax.Position), categoryAttr(tax.Categroy), subCategoryAttr(tax.SubCategory))
 
 	tax.Len = t.b.Len()
 
@@ -477,6 +544,12 @@ func (t *Tracker) Min() uint16 {
 
 }
 
+// BehindWindow() returns the behind window (bw), in packets
+// Time windows change with the packet rate
+func (t *Tracker) BehindWindow() uint16 {
+	return t.bw
+}
+
 // itemsDescending() iterates descending, returning the list of items
 // Try not to use this function frequently ( expensive )
 func (t *Tracker) itemsDescending() (items []uint16) {
@@ -0,0 +1,116 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Config analysis
+//
+// validateNew checks each window, and buffer, on its own.  Analyze checks
+// the configuration as a whole.
+//
+// The sequence numbers are ordered using isLessBranchless, which flips
+// direction at 2^15, but MaxWindowCst limits aw+ab+bw+bb to 6000, so the
+// validation already keeps every valid configuration well under 2^15.
+//
+// An encoder restart starts from a new random sequence number.  The
+// restart is only detected if the new sequence number lands beyond the
+// buffers, so there is a chance of aw+ab+bw+bb+1 in 2^16 that a legitimate
+// restart is not detected.  Landing in the windows looks like a jump, and
+// landing in the buffers is ignored.
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// FalseNonRestartMaxCst is the largest acceptable probability of not detecting a restart
+	FalseNonRestartMaxCst = 0.05
+
+	seqSpaceCst = 1 << 16
+)
+
+// Hazard
+const (
+	HazardUnknown int = iota
+	HazardFalseNonRestart
+)
+
+// Hazard is a problem with the configuration as a whole
+type Hazard struct {
+	Kind    int
+	Message string
+}
+
+// Analysis is the result of Config.Analyze
+type Analysis struct {
+	// windows used, which for time windows is at the configured rate
+	AW uint16
+	BW uint16
+	AB uint16
+	BB uint16
+
+	Span int // aw+ab+bw+bb+1 sequence numbers which are not a restart
+
+	// probability that a restart to a random sequence number is not detected
+	FalseNonRestart float64
+	InWindow        float64 // the restart looks like a jump
+	InBuffer        float64 // the restart is ignored
+
+	Hazards []Hazard
+	Err     error // validation errors, as returned by NewWithConfig
+}
+
+// Analyze checks the Config as a whole, reporting the hazards, and the
+// probability of not detecting a restart
+// Time windows are converted to packets at Time.Rate, or TimeWindowRateCst
+func (c Config) Analyze() (a Analysis) {
+
+	if c.Degree == 0 {
+		c.Degree = BtreeDegreeCst
+	}
+
+	a.AW, a.BW, a.AB, a.BB = c.AW, c.BW, c.AB, c.BB
+	if c.Time.isSet() {
+		tw := &timeWindows{config: c.Time, rate: c.Time.Rate}
+		if tw.rate <= 0 || math.IsNaN(tw.rate) || math.IsInf(tw.rate, 0) {
+			tw.rate = TimeWindowRateCst
+		}
+		a.AW = tw.windowPackets(c.Time.AW)
+		a.BW = tw.windowPackets(c.Time.BW)
+		a.AB = tw.windowPackets(c.Time.AB)
+		a.BB = tw.windowPackets(c.Time.BB)
+	}
+
+	a.Span = int(a.AW) + int(a.AB) + int(a.BW) + int(a.BB) + 1
+
+	a.InWindow = float64(int(a.AW)+int(a.BW)+1) / seqSpaceCst
+	a.InBuffer = float64(int(a.AB)+int(a.BB)) / seqSpaceCst
+	a.FalseNonRestart = float64(a.Span) / seqSpaceCst
+
+	if a.FalseNonRestart > FalseNonRestartMaxCst {
+		a.Hazards = append(a.Hazards, Hazard{
+			Kind:    HazardFalseNonRestart,
+			Message: fmt.Sprintf("restart not detected probability:%.4f > %.4f", a.FalseNonRestart, FalseNonRestartMaxCst),
+		})
+	}
+
+	lt := uint16(LateThresholdCst)
+	if c.LateThreshold != nil {
+		lt = *c.LateThreshold
+	}
+
+	if c.Time.isSet() {
+		a.Err = errors.Join(validateTimeWindows(c.Time), validateDegree(c.Degree), validateStorage(c.Storage))
+	} else {
+		a.Err = errors.Join(validateNew(c.AW, c.BW, c.AB, c.BB, c.Degree), validateStorage(c.Storage),
+			validateLateThreshold(lt, c.BW))
+	}
+
+	return a
+}
+
+// FalseNonRestartN is the probability of at least one of n restarts not being detected
+func (a Analysis) FalseNonRestartN(n int) float64 {
+	return 1 - math.Pow(1-a.FalseNonRestart, float64(n))
+}
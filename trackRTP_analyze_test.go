@@ -0,0 +1,80 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestConfigAnalyze(t *testing.T) {
+
+	type test struct {
+		name    string
+		config  Config
+		span    int
+		hazards []int
+		err     error
+	}
+
+	lt0, lt11 := uint16(0), uint16(11)
+
+	tests := []test{
+		{"default", DefaultConfig(), 401, nil, nil},
+		{"zero degree", Config{AW: 10, BW: 10, AB: 10, BB: 10}, 41, nil, nil},
+		{"max", Config{AW: MaxWindowCst, BW: MaxWindowCst, AB: MaxWindowCst, BB: MaxWindowCst}, 6001, []int{HazardFalseNonRestart}, nil},
+		{"under the hazard", Config{AW: 800, BW: 800, AB: 800, BB: 800}, 3201, nil, nil},
+		// invalid configurations are still analyzed, and a.Err has the validation errors
+		{"span", Config{AW: 2000, BW: 2000, AB: 2000, BB: 2000}, 8001, []int{HazardFalseNonRestart}, ErrWindowAWMax},
+		{"half range", Config{AW: 1000, BW: 100, AB: 32000, BB: 100}, 33201, []int{HazardFalseNonRestart}, ErrWindowABMax},
+		{"late threshold", Config{AW: 10, BW: 10, AB: 10, BB: 10, LateThreshold: &lt11}, 41, nil, ErrLateThresholdMax},
+		{"late threshold zero", Config{AW: 10, BW: 10, AB: 10, BB: 10, LateThreshold: &lt0}, 41, nil, nil},
+		{"time partial", Config{Time: TimeWindows{Rate: 1000}}, 4*(MinWindowCst+1) + 1, nil, ErrTimeWindowBW},
+		// 1000 packets per second, so the windows are milliseconds
+		{"time", Config{Time: TimeWindows{AW: 100 * time.Millisecond, BW: 100 * time.Millisecond,
+			AB: 500 * time.Millisecond, BB: 500 * time.Millisecond, Rate: 1000}}, 1201, nil, nil},
+		{"time default rate", Config{Time: TimeWindows{AW: time.Second, BW: time.Second, AB: time.Second, BB: time.Second}},
+			TimeWindowRateCst*4 + 1, nil, nil},
+	}
+
+	for i, tc := range tests {
+
+		a := tc.config.Analyze()
+
+		if a.Span != tc.span {
+			t.Fatalf("%s, test:%d %s a.Span:%d != tc.span:%d", t.Name(), i, tc.name, a.Span, tc.span)
+		}
+		if p := float64(tc.span) / 65536; a.FalseNonRestart != p {
+			t.Fatalf("%s, test:%d %s a.FalseNonRestart:%f != p:%f", t.Name(), i, tc.name, a.FalseNonRestart, p)
+		}
+		if math.Abs(a.InWindow+a.InBuffer-a.FalseNonRestart) > 1e-12 {
+			t.Fatalf("%s, test:%d %s a.InWindow:%f + a.InBuffer:%f != a.FalseNonRestart:%f",
+				t.Name(), i, tc.name, a.InWindow, a.InBuffer, a.FalseNonRestart)
+		}
+
+		if len(a.Hazards) != len(tc.hazards) {
+			t.Fatalf("%s, test:%d %s a.Hazards:%v != tc.hazards:%v", t.Name(), i, tc.name, a.Hazards, tc.hazards)
+		}
+		for j, h := range a.Hazards {
+			if h.Kind != tc.hazards[j] {
+				t.Fatalf("%s, test:%d %s a.Hazards[%d]:%v != tc.hazards[%d]:%d", t.Name(), i, tc.name, j, h, j, tc.hazards[j])
+			}
+		}
+
+		if !errors.Is(a.Err, tc.err) {
+			t.Fatalf("%s, test:%d %s a.Err:%v != tc.err:%v", t.Name(), i, tc.name, a.Err, tc.err)
+		}
+	}
+
+	a := DefaultConfig().Analyze()
+	if a.FalseNonRestartN(0) != 0 || a.FalseNonRestartN(1) != a.FalseNonRestart {
+		t.Fatalf("%s, FalseNonRestartN(0):%f FalseNonRestartN(1):%f", t.Name(), a.FalseNonRestartN(0), a.FalseNonRestartN(1))
+	}
+	if n := a.FalseNonRestartN(100); n < 0.45 || n > 0.46 {
+		t.Fatalf("%s, FalseNonRestartN(100):%f", t.Name(), n)
+	}
+}
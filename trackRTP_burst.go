@@ -0,0 +1,235 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Loss bursts, gaps, and the Gilbert-Elliott model
+//
+// A single lost packet and a burst of 40 lost packets have the same effect
+// on the lost counter, but a very different effect on a video decoder.
+//
+// Whether a sequence number was received is only final once it falls off
+// the back of the window, so the bursts are counted as the items are
+// evicted, in sequence number order.  The run lengths of consecutive lost
+// ( burst ), and consecutive received ( gap ), sequence numbers are
+// counted in histograms using the JumpBuckets.
+//
+// The Gilbert-Elliott model has a good and a bad state, with a loss
+// probability in each:
+//
+//	p    the probability of moving from the good to the bad state
+//	r    the probability of moving from the bad to the good state
+//	1-k  the loss probability in the good state
+//	h    the probability a packet is received in the bad state
+//
+// The states are not observable, so the packets are split into the states
+// using the RFC 3611 Gmin rule.  A bad state ( burst ) starts and ends with
+// a loss, and contains no run of Gmin or more received packets.  Isolated
+// losses, with at least Gmin received packets either side, are in the good
+// state ( gap ).  The model is then fitted by counting the transitions,
+// and the losses, in each state.
+//
+// https://www.rfc-editor.org/rfc/rfc3611#section-4.7.2
+
+const (
+	// BurstGminCst is the RFC 3611 recommended Gmin
+	BurstGminCst = 16
+)
+
+// Bursts holds the loss burst and gap counters
+// The counters are cumulative, so the model for an interval is fitted
+// using the difference between two snapshots, see Sub()
+type Bursts struct {
+	// Run length histograms, see JumpBuckets
+	// The buckets are not cumulative
+	BurstHistogram [JumpBucketCount]uint64 // consecutive lost sequence numbers
+	GapHistogram   [JumpBucketCount]uint64 // consecutive received sequence numbers
+	BurstTotal     uint64                  // sum of the burst lengths
+	GapTotal       uint64                  // sum of the gap lengths
+
+	// Gilbert-Elliott state counters
+	GoodPackets uint64 // sequence numbers in the good state
+	GoodLost    uint64 // lost in the good state
+	BadPackets  uint64 // sequence numbers in the bad state
+	BadReceived uint64 // received in the bad state
+	GoodToBad   uint64 // transitions from good to bad
+	BadToGood   uint64 // transitions from bad to good
+
+	// run length
+	run     uint64
+	runLost bool
+
+	// Gmin state
+	inBurst   bool
+	pending   bool   // a loss, not yet known to be isolated or the start of a burst
+	sinceLoss uint64 // received since the last loss, while inBurst or pending
+}
+
+// GilbertElliott are the fitted model parameters
+// The parameters are zero (0) when there were no packets in the state
+type GilbertElliott struct {
+	P        float64 // good to bad transition probability
+	R        float64 // bad to good transition probability
+	LossGood float64 // 1-k, the loss probability in the good state
+	H        float64 // the probability of receiving in the bad state
+}
+
+// runEnd counts the current run in the histograms
+func (b *Bursts) runEnd() {
+
+	if b.run == 0 {
+		return
+	}
+
+	i := JumpBucketCount - 1
+	if b.run <= uint64(JumpBuckets[len(JumpBuckets)-1]) {
+		i = jumpBucket(uint16(b.run))
+	}
+
+	if b.runLost {
+		b.BurstHistogram[i]++
+		b.BurstTotal += b.run
+	} else {
+		b.GapHistogram[i]++
+		b.GapTotal += b.run
+	}
+	b.run = 0
+}
+
+// runAdd extends the run, or ends it, and starts another
+func (b *Bursts) runAdd(lost bool, n uint64) {
+
+	if b.run > 0 && b.runLost != lost {
+		b.runEnd()
+	}
+	b.runLost = lost
+	b.run += n
+}
+
+// resolve ends the burst, or the pending loss, after Gmin received packets
+func (b *Bursts) resolve() {
+
+	if b.inBurst {
+		b.inBurst = false
+		b.BadToGood++
+	}
+	if b.pending {
+		b.pending = false
+		b.GoodPackets++
+		b.GoodLost++
+	}
+	b.GoodPackets += b.sinceLoss
+	b.sinceLoss = 0
+}
+
+// received counts a received sequence number
+func (b *Bursts) received() {
+
+	b.runAdd(false, 1)
+
+	if !b.inBurst && !b.pending {
+		b.GoodPackets++
+		return
+	}
+
+	b.sinceLoss++
+	if b.sinceLoss >= BurstGminCst {
+		b.resolve()
+	}
+}
+
+// lost counts n consecutive lost sequence numbers
+func (b *Bursts) lost(n uint16) {
+
+	if n == 0 {
+		return
+	}
+
+	b.runAdd(true, uint64(n))
+
+	if !b.inBurst && !b.pending {
+		b.pending = true
+		b.sinceLoss = 0
+		n--
+		if n == 0 {
+			return
+		}
+	}
+
+	// Within Gmin of the previous loss, so the bad state
+	if !b.inBurst {
+		b.inBurst = true
+		b.pending = false
+		b.GoodToBad++
+		b.BadPackets++
+	}
+	b.BadPackets += b.sinceLoss + uint64(n)
+	b.BadReceived += b.sinceLoss
+	b.sinceLoss = 0
+}
+
+// end finishes the runs, and resolves the Gmin state, when the sequence
+// numbers are no longer continuous, e.g. a restart
+func (b *Bursts) end() {
+	b.runEnd()
+	b.resolve()
+}
+
+// Sub returns the counters for the interval since the prev snapshot
+func (b Bursts) Sub(prev Bursts) Bursts {
+
+	d := b
+	for i := range d.BurstHistogram {
+		d.BurstHistogram[i] -= prev.BurstHistogram[i]
+		d.GapHistogram[i] -= prev.GapHistogram[i]
+	}
+	d.BurstTotal -= prev.BurstTotal
+	d.GapTotal -= prev.GapTotal
+	d.GoodPackets -= prev.GoodPackets
+	d.GoodLost -= prev.GoodLost
+	d.BadPackets -= prev.BadPackets
+	d.BadReceived -= prev.BadReceived
+	d.GoodToBad -= prev.GoodToBad
+	d.BadToGood -= prev.BadToGood
+
+	return d
+}
+
+// GilbertElliott fits the model to the counters
+func (b Bursts) GilbertElliott() (ge GilbertElliott) {
+
+	if b.GoodPackets > 0 {
+		ge.P = float64(b.GoodToBad) / float64(b.GoodPackets)
+		ge.LossGood = float64(b.GoodLost) / float64(b.GoodPackets)
+	}
+	if b.BadPackets > 0 {
+		ge.R = float64(b.BadToGood) / float64(b.BadPackets)
+		ge.H = float64(b.BadReceived) / float64(b.BadPackets)
+	}
+
+	return ge
+}
+
+// walkBursts counts the bursts and gaps of the sequence numbers from
+// t.back up to, but not including, back, and must be called before the
+// items are deleted
+func (t *Tracker) walkBursts(back uint16) {
+
+	// Has() over the range, rather than Ascend() with a closure, so
+	// evicting doesn't allocate
+	var lost uint16
+	for seq := t.back; isLess(seq, back); seq++ {
+		if !t.b.Has(seq) {
+			lost++
+			continue
+		}
+		t.bursts.lost(lost)
+		lost = 0
+		t.bursts.received()
+	}
+	t.bursts.lost(lost)
+}
+
+// Bursts() returns a copy of the loss burst and gap counters
+func (t *Tracker) Bursts() Bursts {
+	return t.bursts
+}
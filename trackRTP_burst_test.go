@@ -0,0 +1,124 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBursts(t *testing.T) {
+
+	type test struct {
+		name       string
+		pattern    string // R received, L lost
+		bursts     uint64
+		burstTotal uint64
+		gaps       uint64
+		gapTotal   uint64
+		ge         GilbertElliott
+	}
+
+	r20 := strings.Repeat("R", 20)
+
+	tests := []test{
+		{"none", "", 0, 0, 0, 0, GilbertElliott{}},
+		// the last run is still open
+		{"received", r20, 0, 0, 0, 0, GilbertElliott{}},
+		// 41 packets in the good state, with a single loss
+		{"isolated", r20 + "L" + r20, 1, 1, 1, 20, GilbertElliott{LossGood: 1.0 / 41}},
+		// 5 packets in the bad state, 1 received, and 40 in the good state
+		{"burst", r20 + "LRLLL" + r20, 2, 4, 2, 21, GilbertElliott{P: 1.0 / 40, R: 1.0 / 5, H: 1.0 / 5}},
+		// less than Gmin received, so still the bad state
+		{"gmin", r20 + "L" + strings.Repeat("R", BurstGminCst-1) + "L" + r20, 2, 2, 2, 35,
+			GilbertElliott{P: 1.0 / 40, R: 1.0 / 17, H: 15.0 / 17}},
+		// Gmin received, so the losses are both isolated
+		{"gmin isolated", r20 + "L" + strings.Repeat("R", BurstGminCst) + "L" + r20, 2, 2, 2, 36,
+			GilbertElliott{LossGood: 2.0 / 58}},
+	}
+
+	for i, tc := range tests {
+
+		var b Bursts
+		for _, c := range tc.pattern {
+			if c == 'L' {
+				b.lost(1)
+				continue
+			}
+			b.received()
+		}
+
+		var bursts, gaps uint64
+		for j := range b.BurstHistogram {
+			bursts += b.BurstHistogram[j]
+			gaps += b.GapHistogram[j]
+		}
+
+		if bursts != tc.bursts || b.BurstTotal != tc.burstTotal {
+			t.Fatalf("%s, test:%d %s bursts:%d BurstTotal:%d != tc.bursts:%d tc.burstTotal:%d",
+				t.Name(), i, tc.name, bursts, b.BurstTotal, tc.bursts, tc.burstTotal)
+		}
+		if gaps != tc.gaps || b.GapTotal != tc.gapTotal {
+			t.Fatalf("%s, test:%d %s gaps:%d GapTotal:%d != tc.gaps:%d tc.gapTotal:%d",
+				t.Name(), i, tc.name, gaps, b.GapTotal, tc.gaps, tc.gapTotal)
+		}
+		if ge := b.GilbertElliott(); ge != tc.ge {
+			t.Fatalf("%s, test:%d %s GilbertElliott():%+v != tc.ge:%+v", t.Name(), i, tc.name, ge, tc.ge)
+		}
+
+		// the counters are cumulative, so the interval since the start is the same
+		if ge := b.Sub(Bursts{}).GilbertElliott(); ge != tc.ge {
+			t.Fatalf("%s, test:%d %s Sub().GilbertElliott():%+v != tc.ge:%+v", t.Name(), i, tc.name, ge, tc.ge)
+		}
+	}
+}
+
+func TestBurstsTracker(t *testing.T) {
+
+	type test struct {
+		name  string
+		seqs  []uint16
+		lost  uint64
+		ge    GilbertElliott
+		burst [JumpBucketCount]uint64
+	}
+
+	tests := []test{
+		// 0 to 12 fall off the back, with 3 and 4 lost together, and 10 lost within Gmin
+		{"evicted", []uint16{0, 1, 2, 5, 6, 7, 8, 9, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+			3, GilbertElliott{P: 1.0 / 3, H: 5.0 / 8}, [JumpBucketCount]uint64{1, 1}},
+		// the restart walks the old window, and ends the burst
+		{"restart", []uint16{0, 1, 2, 5, 6, 30000},
+			2, GilbertElliott{P: 1.0 / 5, R: 1.0 / 2}, [JumpBucketCount]uint64{0, 1}},
+	}
+
+	for i, tc := range tests {
+
+		tr, err := New(4, 4, 4, 4, debugLevelCst)
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+
+		for _, seq := range tc.seqs {
+			_, e := tr.PacketArrival(seq)
+			if e != nil {
+				t.Fatalf("%s, test:%d %s err != nil:%v", t.Name(), i, tc.name, e)
+			}
+		}
+
+		b := tr.Bursts()
+		s := tr.Stats()
+
+		if s.Lost != tc.lost || b.BurstTotal != tc.lost {
+			t.Fatalf("%s, test:%d %s Lost:%d BurstTotal:%d != tc.lost:%d", t.Name(), i, tc.name, s.Lost, b.BurstTotal, tc.lost)
+		}
+		if ge := b.GilbertElliott(); ge != tc.ge {
+			t.Fatalf("%s, test:%d %s GilbertElliott():%+v != tc.ge:%+v", t.Name(), i, tc.name, ge, tc.ge)
+		}
+		if b.BurstHistogram != tc.burst {
+			t.Fatalf("%s, test:%d %s BurstHistogram:%v != tc.burst:%v", t.Name(), i, tc.name, b.BurstHistogram, tc.burst)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Event callbacks
+//
+// The callbacks are invoked synchronously from PacketArrival, so alarms or
+// packet captures can be triggered without post-processing every Taxonomy.
+//
+// OnEvictedLoss is invoked as the window moves, and then, once the packet
+// is classified, and any time window eviction is done, the callback for
+// the packet.  So the Taxonomy passed is the one PacketArrival returns,
+// before the Stats are updated, with packet, and time, windows.
+//
+// The callbacks must not call back into the Tracker, and with SafeTracker
+// they are invoked holding the lock, so they should be quick.
+
+// Callbacks are the functions invoked on the classification outcomes
+// nil callbacks are skipped
+type Callbacks struct {
+	// OnRestart is invoked when the window restarts
+	OnRestart func(seq uint16, tax *Taxonomy)
+
+	// OnBufferHit is invoked when the packet is in the ahead, or behind, buffer
+	OnBufferHit func(seq uint16, tax *Taxonomy)
+
+	// OnDuplicate is invoked for duplicates of Max(), or within the windows
+	OnDuplicate func(seq uint16, tax *Taxonomy)
+
+	// OnJump is invoked when the packet jumps ahead of Max() by more than one (1)
+	OnJump func(seq uint16, size uint16)
+
+	// OnEvictedLoss is invoked with the sequence numbers which were never
+	// received, as they fall off the back of the window, including by
+	// arrival time, or are abandoned by a restart.  It's invoked before the
+	// callback for the packet which moved the window, and seqs is only
+	// valid during the call.
+	OnEvictedLoss func(seqs []uint16)
+}
+
+// SetCallbacks replaces the callbacks
+func (t *Tracker) SetCallbacks(cb Callbacks) {
+	t.callbacks = cb
+}
+
+// packetCallbacks invokes the callback for the packet's classification
+func (t *Tracker) packetCallbacks(seq uint16, tax *Taxonomy) {
+
+	switch {
+	case tax.Position == PositionDuplicate:
+		if t.callbacks.OnDuplicate != nil {
+			t.callbacks.OnDuplicate(seq, tax)
+		}
+	case tax.Categroy == CategoryRestart:
+		if t.callbacks.OnRestart != nil {
+			t.callbacks.OnRestart(seq, tax)
+		}
+	case tax.Categroy == CategoryBuffer:
+		if t.callbacks.OnBufferHit != nil {
+			t.callbacks.OnBufferHit(seq, tax)
+		}
+	case tax.SubCategory == SubCategoryDuplicate:
+		if t.callbacks.OnDuplicate != nil {
+			t.callbacks.OnDuplicate(seq, tax)
+		}
+	case tax.Position == PositionAhead && tax.SubCategory == SubCategoryJump:
+		if t.callbacks.OnJump != nil {
+			t.callbacks.OnJump(seq, tax.Jump)
+		}
+	}
+}
+
+// evictedLoss invokes OnEvictedLoss with the sequence numbers from t.back
+// up to, but not including, back, which are not in the storage
+// This must be called before the items are deleted
+func (t *Tracker) evictedLoss(back uint16) {
+
+	if t.callbacks.OnEvictedLoss == nil {
+		return
+	}
+
+	t.evicted = t.evicted[:0]
+
+	for seq := t.back; isLess(seq, back); seq++ {
+		if !t.b.Has(seq) {
+			t.evicted = append(t.evicted, seq)
+		}
+	}
+
+	if len(t.evicted) > 0 {
+		t.callbacks.OnEvictedLoss(t.evicted)
+	}
+}
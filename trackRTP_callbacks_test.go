@@ -0,0 +1,150 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCallbacks(t *testing.T) {
+
+	type test struct {
+		seq    uint16
+		events []string
+	}
+
+	tests := []test{
+		{0, nil},
+		{1, nil},
+		{1, []string{"duplicate:1"}},
+		{4, []string{"jump:4:3"}},
+		{3, nil},
+		{3, []string{"duplicate:3"}},
+		{18, []string{"buffer:18"}},
+		{14, []string{"jump:14:10"}},
+		{20, []string{"jump:20:6"}},
+		// 2 and 5 fall off the back
+		{25, []string{"evicted:[2 5]", "jump:25:5"}},
+		// the restart abandons the rest of the window
+		{1000, []string{"evicted:[6 7 8 9 10 11 12 13 15 16 17 18 19 21 22 23 24]", "restart:1000"}},
+		{1001, nil},
+	}
+
+	for _, storage := range testStorages {
+
+		var events []string
+		cb := Callbacks{
+			OnRestart:   func(seq uint16, tax *Taxonomy) { events = append(events, fmt.Sprintf("restart:%d", seq)) },
+			OnBufferHit: func(seq uint16, tax *Taxonomy) { events = append(events, fmt.Sprintf("buffer:%d", seq)) },
+			OnDuplicate: func(seq uint16, tax *Taxonomy) { events = append(events, fmt.Sprintf("duplicate:%d", seq)) },
+			OnJump: func(seq uint16, size uint16) {
+				events = append(events, fmt.Sprintf("jump:%d:%d", seq, size))
+			},
+			OnEvictedLoss: func(seqs []uint16) { events = append(events, fmt.Sprintf("evicted:%v", seqs)) },
+		}
+
+		tr, err := NewWithConfig(DefaultConfig(), WithAheadWindow(10), WithBehindWindow(10),
+			WithAheadBuffer(10), WithBehindBuffer(10), WithStorage(storage), WithCallbacks(cb))
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+
+		for i, tc := range tests {
+
+			events = nil
+
+			_, err := tr.PacketArrival(tc.seq)
+			if err != nil {
+				t.Fatalf("%s, storage:%d test:%d PacketArrival err:%v", t.Name(), storage, i, err)
+			}
+
+			if !reflect.DeepEqual(events, tc.events) {
+				t.Fatalf("%s, storage:%d test:%d seq:%d events:%v != tc.events:%v", t.Name(), storage, i, tc.seq, events, tc.events)
+			}
+		}
+
+		// the evicted sequence numbers are the losses
+		if s := tr.Stats(); s.Lost != 2+17 {
+			t.Fatalf("%s, storage:%d Stats().Lost:%d != 19", t.Name(), storage, s.Lost)
+		}
+
+		// no callbacks
+		tr.SetCallbacks(Callbacks{})
+		events = nil
+		for _, seq := range []uint16{1001, 1003, 1020, 5000} {
+			_, err := tr.PacketArrival(seq)
+			if err != nil {
+				t.Fatalf("%s, storage:%d PacketArrival err:%v", t.Name(), storage, err)
+			}
+		}
+		if events != nil {
+			t.Fatalf("%s, storage:%d events:%v after SetCallbacks(Callbacks{})", t.Name(), storage, events)
+		}
+	}
+}
+
+func TestCallbacksTime(t *testing.T) {
+
+	type test struct {
+		seq    uint16
+		offset time.Duration
+		events []string
+	}
+
+	ms := time.Millisecond
+
+	// 100 packets per second, so the windows are 10 packets
+	tests := []test{
+		{0, 0, nil},
+		{1, 10 * ms, nil},
+		{2, 20 * ms, nil},
+		{4, 40 * ms, []string{"jump:4:2"}},
+		{5, 50 * ms, nil},
+		// 0 to 5 arrived before the behind window, so 3 is evicted by time,
+		// before the callback for the packet
+		{8, 300 * ms, []string{"evicted:[3]", "jump:8:3"}},
+		// the duplicate's Taxonomy includes the time window eviction
+		{8, 500 * ms, []string{"evicted:[6 7]", "duplicate:8 lost:2 len:1"}},
+	}
+
+	for _, storage := range testStorages {
+
+		var events []string
+		cb := Callbacks{
+			OnDuplicate: func(seq uint16, tax *Taxonomy) {
+				events = append(events, fmt.Sprintf("duplicate:%d lost:%d len:%d", seq, tax.Lost, tax.Len))
+			},
+			OnJump: func(seq uint16, size uint16) {
+				events = append(events, fmt.Sprintf("jump:%d:%d", seq, size))
+			},
+			OnEvictedLoss: func(seqs []uint16) { events = append(events, fmt.Sprintf("evicted:%v", seqs)) },
+		}
+
+		tr, err := NewWithConfig(DefaultConfig(), WithStorage(storage), WithCallbacks(cb),
+			WithTimeWindows(TimeWindows{AW: 100 * ms, BW: 100 * ms, AB: time.Second, BB: time.Second, Rate: 100}))
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+
+		start := time.Unix(1700000000, 0)
+
+		for i, tc := range tests {
+
+			events = nil
+
+			_, err := tr.PacketArrivalAt(tc.seq, start.Add(tc.offset))
+			if err != nil {
+				t.Fatalf("%s, storage:%d test:%d PacketArrivalAt err:%v", t.Name(), storage, i, err)
+			}
+
+			if !reflect.DeepEqual(events, tc.events) {
+				t.Fatalf("%s, storage:%d test:%d seq:%d events:%v != tc.events:%v", t.Name(), storage, i, tc.seq, events, tc.events)
+			}
+		}
+	}
+}
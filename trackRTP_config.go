@@ -0,0 +1,153 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Config, and functional options, for creating a Tracker
+//
+// New(aw, bw, ab, bb, debugLevel) takes the windows as positional uint16s,
+// which are easy to transpose.  NewWithConfig takes them by name:
+//
+//	t, err := NewWithConfig(DefaultConfig(),
+//		WithBehindWindow(500),
+//		WithStorage(StorageBitmap),
+//	)
+
+import (
+	"log/slog"
+)
+
+const (
+	// WindowCst is the default for each of the windows, and buffers
+	WindowCst = 100
+)
+
+// Config is the Tracker configuration
+type Config struct {
+	AW uint16 // ahead window
+	BW uint16 // behind window
+	AB uint16 // ahead buffer
+	BB uint16 // behind buffer
+
+	Degree        int     // B-tree degree, zero (0) is BtreeDegreeCst
+	Storage       int     // StorageBTree, or StorageBitmap
+	LateThreshold *uint16 // nil is LateThresholdCst, so zero (0) can be set
+
+	// Time, if any field is set, is used instead of the packet windows
+	Time TimeWindows
+
+	Callbacks Callbacks
+
+	DebugLevel int
+	Logger     *slog.Logger // nil discards, unless DebugLevel > DebugLevelLogCst
+}
+
+// Option modifies the Config
+type Option func(*Config)
+
+// DefaultConfig returns the default Config, with all the windows WindowCst
+func DefaultConfig() Config {
+	return Config{
+		AW:      WindowCst,
+		BW:      WindowCst,
+		AB:      WindowCst,
+		BB:      WindowCst,
+		Degree:  BtreeDegreeCst,
+		Storage: StorageBTree,
+	}
+}
+
+// WithAheadWindow sets the ahead window
+func WithAheadWindow(aw uint16) Option {
+	return func(c *Config) { c.AW = aw }
+}
+
+// WithBehindWindow sets the behind window
+func WithBehindWindow(bw uint16) Option {
+	return func(c *Config) { c.BW = bw }
+}
+
+// WithAheadBuffer sets the ahead buffer
+func WithAheadBuffer(ab uint16) Option {
+	return func(c *Config) { c.AB = ab }
+}
+
+// WithBehindBuffer sets the behind buffer
+func WithBehindBuffer(bb uint16) Option {
+	return func(c *Config) { c.BB = bb }
+}
+
+// WithDegree sets the B-tree degree
+func WithDegree(degree int) Option {
+	return func(c *Config) { c.Degree = degree }
+}
+
+// WithStorage sets the storage, StorageBTree, or StorageBitmap
+func WithStorage(storage int) Option {
+	return func(c *Config) { c.Storage = storage }
+}
+
+// WithLateThreshold sets the late threshold, including zero (0), see SetLateThreshold
+func WithLateThreshold(lt uint16) Option {
+	return func(c *Config) { c.LateThreshold = &lt }
+}
+
+// WithTimeWindows uses the time windows, instead of the packet windows
+func WithTimeWindows(tw TimeWindows) Option {
+	return func(c *Config) { c.Time = tw }
+}
+
+// WithCallbacks sets the event callbacks, see SetCallbacks
+func WithCallbacks(cb Callbacks) Option {
+	return func(c *Config) { c.Callbacks = cb }
+}
+
+// WithDebugLevel sets the debugLevel
+func WithDebugLevel(debugLevel int) Option {
+	return func(c *Config) { c.DebugLevel = debugLevel }
+}
+
+// WithLogger sets the logger for the Tracker diagnostics, see SetLogger
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) { c.Logger = logger }
+}
+
+// NewWithConfig creates a Tracker from the Config, with the options applied
+// Invalid configurations return a *ValidationError for each violation, joined using errors.Join
+func NewWithConfig(config Config, opts ...Option) (*Tracker, error) {
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.Degree == 0 {
+		config.Degree = BtreeDegreeCst
+	}
+	var (
+		t   *Tracker
+		err error
+	)
+	if config.Time.isSet() {
+		t, err = newTimeTracker(config.Time, config.Storage, config.Degree, config.DebugLevel)
+	} else {
+		t, err = newTracker(config.AW, config.BW, config.AB, config.BB, config.Storage, config.Degree, config.DebugLevel)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// time windows set the behind window from the rate, so this uses the Tracker's
+	if config.LateThreshold != nil {
+		err = t.SetLateThreshold(*config.LateThreshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Logger != nil {
+		t.SetLogger(config.Logger)
+	}
+
+	t.SetCallbacks(config.Callbacks)
+
+	return t, nil
+}
@@ -0,0 +1,88 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestNewWithConfig(t *testing.T) {
+
+	type test struct {
+		name  string
+		opts  []Option
+		aw    uint16
+		bw    uint16
+		ab    uint16
+		bb    uint16
+		lt    uint16
+		field string
+		err   error
+	}
+
+	tests := []test{
+		{"default", nil, WindowCst, WindowCst, WindowCst, WindowCst, LateThresholdCst, "", nil},
+		{"windows", []Option{WithAheadWindow(10), WithBehindWindow(20), WithAheadBuffer(30), WithBehindBuffer(40)},
+			10, 20, 30, 40, LateThresholdCst, "", nil},
+		{"late threshold", []Option{WithLateThreshold(50)}, WindowCst, WindowCst, WindowCst, WindowCst, 50, "", nil},
+		{"late threshold zero", []Option{WithLateThreshold(0)}, WindowCst, WindowCst, WindowCst, WindowCst, 0, "", nil},
+		{"bitmap", []Option{WithStorage(StorageBitmap), WithDegree(MaxDegree)}, WindowCst, WindowCst, WindowCst, WindowCst, LateThresholdCst, "", nil},
+		{"aw min", []Option{WithAheadWindow(MinWindowCst)}, 0, 0, 0, 0, 0, "AW", ErrWindowAWMin},
+		{"bw max", []Option{WithBehindWindow(MaxWindowCst + 1)}, 0, 0, 0, 0, 0, "BW", ErrWindowBWMax},
+		{"ab min", []Option{WithAheadBuffer(0)}, 0, 0, 0, 0, 0, "AB", ErrWindowABMin},
+		{"bb max", []Option{WithBehindBuffer(MaxWindowCst + 1)}, 0, 0, 0, 0, 0, "BB", ErrWindowBBMax},
+		{"degree", []Option{WithDegree(MaxDegree + 1)}, 0, 0, 0, 0, 0, "Degree", ErrWindowDegreeMax},
+		{"storage", []Option{WithStorage(StorageBitmap + 1)}, 0, 0, 0, 0, 0, "Storage", ErrStorage},
+		{"late threshold max", []Option{WithLateThreshold(WindowCst + 1)}, 0, 0, 0, 0, 0, "LateThreshold", ErrLateThresholdMax},
+		{"time", []Option{WithTimeWindows(TimeWindows{AW: -time.Second, BW: time.Second, AB: time.Second, BB: time.Second})},
+			0, 0, 0, 0, 0, "Time.AW", ErrTimeWindowAW},
+		// a partial time configuration is invalid, rather than falling back to the packet windows
+		{"time partial", []Option{WithTimeWindows(TimeWindows{AW: time.Second, AB: time.Second, BB: time.Second})},
+			0, 0, 0, 0, 0, "Time.BW", ErrTimeWindowBW},
+	}
+
+	for i, tc := range tests {
+
+		tr, err := NewWithConfig(DefaultConfig(), tc.opts...)
+
+		if tc.err != nil {
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+			}
+			var ve *ValidationError
+			if !errors.As(err, &ve) || ve.Field != tc.field {
+				t.Fatalf("%s, test:%d %s err:%v field != tc.field:%s", t.Name(), i, tc.name, err, tc.field)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s, test:%d %s err:%v", t.Name(), i, tc.name, err)
+		}
+		if tr.aw != tc.aw || tr.bw != tc.bw || tr.ab != tc.ab || tr.bb != tc.bb || tr.lt != tc.lt {
+			t.Fatalf("%s, test:%d %s aw:%d bw:%d ab:%d bb:%d lt:%d", t.Name(), i, tc.name, tr.aw, tr.bw, tr.ab, tr.bb, tr.lt)
+		}
+	}
+
+	// the logger is used, instead of discarding
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tr, err := NewWithConfig(DefaultConfig(), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("%s, WithLogger err:%v", t.Name(), err)
+	}
+	if tr.logger != logger {
+		t.Fatalf("%s, WithLogger logger not set", t.Name())
+	}
+
+	// the zero Config has no windows
+	_, err = NewWithConfig(Config{})
+	if !errors.Is(err, ErrWindowAWMin) {
+		t.Fatalf("%s, zero Config err:%v != ErrWindowAWMin", t.Name(), err)
+	}
+}
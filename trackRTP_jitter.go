@@ -0,0 +1,157 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// RFC 3550 Appendix A.8 Estimating the Interarrival Jitter
+//
+// The windows are all counted in packets, so the jitter needs the RTP
+// timestamp, the clock rate, and the local arrival time as well.
+//
+// The transit time is the arrival time, converted to RTP timestamp units,
+// minus the RTP timestamp.  The sender and receiver clocks aren't
+// synchronized, so the transit times are relative to the first packet.
+//
+// https://www.rfc-editor.org/rfc/rfc3550#appendix-A.8
+// https://www.rfc-editor.org/rfc/rfc3550#section-6.4.1
+
+import (
+	"time"
+)
+
+// Jitter is the interarrival jitter, and transit time, state
+type Jitter struct {
+	ClockRate uint32 // RTP timestamp units per second
+	Packets   uint64 // packets included in the transit times
+
+	jitter  uint32 // jitter scaled by 16, which is the integer version of A.8
+	transit uint32 // previous transit, in timestamp units
+
+	base        time.Time // arrival time of the first packet
+	baseTransit uint32    // transit of the first packet
+
+	min int32 // relative transit, in timestamp units
+	max int32
+	sum int64
+}
+
+// reset forgets the transit times, keeping the jitter
+func (j *Jitter) reset() {
+	j.Packets = 0
+	j.min = 0
+	j.max = 0
+	j.sum = 0
+}
+
+// arrival converts the arrival time into timestamp units since the base
+// Seconds and nanoseconds are converted separately to avoid overflow
+func (j *Jitter) arrival(arrival time.Time) uint32 {
+
+	d := arrival.Sub(j.base)
+	if d < 0 {
+		// the local clock stepped backwards
+		d = 0
+	}
+	sec := uint64(d / time.Second)
+	nanos := uint64(d % time.Second)
+
+	return uint32(sec*uint64(j.ClockRate) + nanos*uint64(j.ClockRate)/uint64(time.Second))
+}
+
+// update adds a packet, from the A.8 pseudo code
+func (j *Jitter) update(rtpTS uint32, arrival time.Time) {
+
+	if j.Packets == 0 {
+		j.base = arrival
+		j.baseTransit = -rtpTS
+		j.transit = j.baseTransit
+		j.Packets = 1
+		return
+	}
+
+	transit := j.arrival(arrival) - rtpTS
+
+	// uint32 subtraction, then int32, handles the timestamp wrap
+	d := int32(transit - j.transit)
+	j.transit = transit
+	if d < 0 {
+		d = -d
+	}
+	j.jitter += uint32(d) - ((j.jitter + 8) >> 4)
+
+	rel := int32(transit - j.baseTransit)
+	if rel < j.min {
+		j.min = rel
+	}
+	if rel > j.max {
+		j.max = rel
+	}
+	j.sum += int64(rel)
+	j.Packets++
+}
+
+// duration converts timestamp units to a duration
+func (j *Jitter) duration(units int64) time.Duration {
+	if j.ClockRate == 0 {
+		return 0
+	}
+	return time.Duration(units * int64(time.Second) / int64(j.ClockRate))
+}
+
+// Jitter returns the interarrival jitter in timestamp units, as used in the
+// RTCP reception report
+func (j *Jitter) Jitter() uint32 {
+	return j.jitter >> 4
+}
+
+// JitterDuration returns the interarrival jitter as a duration
+func (j *Jitter) JitterDuration() time.Duration {
+	return j.duration(int64(j.jitter) >> 4)
+}
+
+// TransitMin returns the minimum transit time, relative to the first packet
+func (j *Jitter) TransitMin() time.Duration {
+	return j.duration(int64(j.min))
+}
+
+// TransitMax returns the maximum transit time, relative to the first packet
+func (j *Jitter) TransitMax() time.Duration {
+	return j.duration(int64(j.max))
+}
+
+// TransitMean returns the mean transit time, relative to the first packet
+func (j *Jitter) TransitMean() time.Duration {
+	if j.Packets == 0 {
+		return 0
+	}
+	return j.duration(j.sum / int64(j.Packets))
+}
+
+// PacketArrivalTimestamp is PacketArrival, with the RTP timestamp and local
+// arrival time, for the RFC 3550 interarrival jitter and transit times
+// A change of clock rate, or a restart of the window, starts the transit
+// times again, while duplicates are ignored
+func (t *Tracker) PacketArrivalTimestamp(seq uint16, rtpTS uint32, clockRate uint32, arrival time.Time) (*Taxonomy, error) {
+
+	tax, err := t.PacketArrivalAt(seq, arrival)
+	if err != nil {
+		return tax, err
+	}
+
+	if tax.Position == PositionDuplicate || tax.SubCategory == SubCategoryDuplicate {
+		return tax, nil
+	}
+
+	if clockRate != t.jitter.ClockRate || tax.Categroy == CategoryRestart {
+		t.jitter.reset()
+		t.jitter.ClockRate = clockRate
+	}
+
+	t.jitter.update(rtpTS, arrival)
+
+	return tax, nil
+}
+
+// Jitter() returns a copy of the jitter state
+func (t *Tracker) Jitter() Jitter {
+	return t.jitter
+}
@@ -0,0 +1,112 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitter(t *testing.T) {
+
+	type test struct {
+		name        string
+		clockRate   uint32
+		seqs        []uint16
+		tss         []uint32
+		arrivals    []time.Duration // since the start
+		Jitter      uint32
+		TransitMin  time.Duration
+		TransitMax  time.Duration
+		TransitMean time.Duration
+		Packets     uint64
+	}
+
+	ms := time.Millisecond
+
+	tests := []test{
+		{"steady", 90000,
+			[]uint16{0, 1, 2, 3, 4},
+			[]uint32{0, 1800, 3600, 5400, 7200},
+			[]time.Duration{0, 20 * ms, 40 * ms, 60 * ms, 80 * ms},
+			0, 0, 0, 0, 5},
+		// 900 units late, so the jitter is 900/16, then 900/16 + (900 - 900/16)/16
+		{"one late", 90000,
+			[]uint16{0, 1, 2, 3},
+			[]uint32{0, 1800, 3600, 5400},
+			[]time.Duration{0, 20 * ms, 50 * ms, 60 * ms},
+			109, 0, 10 * ms, 2500 * time.Microsecond, 4},
+		// 80 units early, and the mean is -80/3 units
+		{"early", 8000,
+			[]uint16{0, 1, 2},
+			[]uint32{0, 160, 320},
+			[]time.Duration{0, 10 * ms, 40 * ms},
+			9, -10 * ms, 0, -3250 * time.Microsecond, 3},
+		// the clock steps back 10ms, which is clamped to the base, so 20ms early
+		{"clock stepped back", 8000,
+			[]uint16{0, 1, 2},
+			[]uint32{0, 160, 320},
+			[]time.Duration{0, -10 * ms, 40 * ms},
+			19, -20 * ms, 0, -6625 * time.Microsecond, 3},
+		{"timestamp wrap", 90000,
+			[]uint16{0, 1, 2, 3},
+			[]uint32{0xFFFFF000, 0xFFFFF708, 0xFFFFFE10, 0x00000518}, // steps of 1800
+			[]time.Duration{0, 20 * ms, 40 * ms, 60 * ms},
+			0, 0, 0, 0, 4},
+		{"long running", 90000,
+			[]uint16{0, 1, 2},
+			[]uint32{0, 3600 * 90000, 2 * 3600 * 90000},
+			[]time.Duration{0, time.Hour, 2 * time.Hour},
+			0, 0, 0, 0, 3},
+		{"duplicate ignored", 90000,
+			[]uint16{0, 1, 1},
+			[]uint32{0, 1800, 1800},
+			[]time.Duration{0, 20 * ms, 100 * ms},
+			0, 0, 0, 0, 2},
+		{"restart", 90000,
+			[]uint16{0, 1, 30000, 30001},
+			[]uint32{0, 1800, 50000000, 50001800},
+			[]time.Duration{0, 20 * ms, 40 * ms, 60 * ms},
+			0, 0, 0, 0, 2},
+	}
+
+	start := time.Unix(1700000000, 0)
+
+	for i, tc := range tests {
+
+		tr, err := New(10, 10, 10, 10, debugLevelCst)
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+
+		for j, seq := range tc.seqs {
+			_, e := tr.PacketArrivalTimestamp(seq, tc.tss[j], tc.clockRate, start.Add(tc.arrivals[j]))
+			if e != nil {
+				t.Fatalf("%s, err != nil:%v", t.Name(), e)
+			}
+		}
+
+		j := tr.Jitter()
+
+		if j.Jitter() != tc.Jitter {
+			t.Fatalf("%s, test:%d %s Jitter():%d != tc.Jitter:%d", t.Name(), i, tc.name, j.Jitter(), tc.Jitter)
+		}
+		if j.TransitMin() != tc.TransitMin {
+			t.Fatalf("%s, test:%d %s TransitMin():%v != tc.TransitMin:%v", t.Name(), i, tc.name, j.TransitMin(), tc.TransitMin)
+		}
+		if j.TransitMax() != tc.TransitMax {
+			t.Fatalf("%s, test:%d %s TransitMax():%v != tc.TransitMax:%v", t.Name(), i, tc.name, j.TransitMax(), tc.TransitMax)
+		}
+		if j.TransitMean() != tc.TransitMean {
+			t.Fatalf("%s, test:%d %s TransitMean():%v != tc.TransitMean:%v", t.Name(), i, tc.name, j.TransitMean(), tc.TransitMean)
+		}
+		if j.Packets != tc.Packets {
+			t.Fatalf("%s, test:%d %s Packets:%d != tc.Packets:%d", t.Name(), i, tc.name, j.Packets, tc.Packets)
+		}
+		if j.JitterDuration() != time.Duration(int64(tc.Jitter)*int64(time.Second)/int64(tc.clockRate)) {
+			t.Fatalf("%s, test:%d %s JitterDuration():%v", t.Name(), i, tc.name, j.JitterDuration())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Logging
+//
+// The Tracker logs its diagnostics using log/slog, at slog.LevelDebug, with
+// consistent attributes ( seq, max, min, len, diff, position, category ), so
+// they can be routed into an existing logging pipeline.
+//
+// The default logger discards everything, so the logging costs a single
+// Enabled() check.  For compatibility, a debugLevel above DebugLevelLogCst,
+// without a logger, logs to stderr at slog.LevelDebug.
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+const (
+	// DebugLevelLogCst is the debugLevel above which the Tracker logs to stderr,
+	// if no logger is set
+	DebugLevelLogCst = 10
+)
+
+var (
+	// the Position, Category, and SubCategory names for the log attributes
+	positionNames    [PositionCount]string
+	categoryNames    [CategoryCount]string
+	subCategoryNames [SubCategoryCount]string
+)
+
+func init() {
+	m := NewMaps()
+	for i := range positionNames {
+		positionNames[i] = m.PosMap[i]
+	}
+	for i := range categoryNames {
+		categoryNames[i] = m.CatMap[i]
+	}
+	for i := range subCategoryNames {
+		subCategoryNames[i] = m.SubCatMap[i]
+	}
+}
+
+// discardHandler is a slog.Handler which discards everything
+// See also: https://pkg.go.dev/log/slog#DiscardHandler in go1.24
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// newLogger returns the default logger for the debugLevel
+func newLogger(debugLevel int) *slog.Logger {
+
+	if debugLevel > DebugLevelLogCst {
+		return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	return slog.New(discardHandler{})
+}
+
+// SetLogger sets the logger for the Tracker diagnostics, which are logged
+// at slog.LevelDebug
+// nil discards the diagnostics
+func (t *Tracker) SetLogger(logger *slog.Logger) {
+
+	if logger == nil {
+		logger = slog.New(discardHandler{})
+	}
+
+	t.logger = logger
+}
+
+// debugEnabled is true if the logger is logging at slog.LevelDebug
+// Use this to guard any work done only to log
+func (t *Tracker) debugEnabled() bool {
+	return t.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// debug logs the msg, and the attributes, at slog.LevelDebug
+func (t *Tracker) debug(msg string, attrs ...slog.Attr) {
+
+	if !t.debugEnabled() {
+		return
+	}
+
+	t.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
+
+func seqAttr(seq uint16) slog.Attr {
+	return slog.Int("seq", int(seq))
+}
+
+func maxAttr(m uint16) slog.Attr {
+	return slog.Int("max", int(m))
+}
+
+func minAttr(min uint16) slog.Attr {
+	return slog.Int("min", int(min))
+}
+
+func lenAttr(l int) slog.Attr {
+	return slog.Int("len", l)
+}
+
+func diffAttr(diff uint16) slog.Attr {
+	return slog.Int("diff", int(diff))
+}
+
+func positionAttr(position int) slog.Attr {
+	return slog.String("position", positionNames[position])
+}
+
+func categoryAttr(category int) slog.Attr {
+	return slog.String("category", categoryNames[category])
+}
+
+func subCategoryAttr(subCategory int) slog.Attr {
+	return slog.String("subCategory", subCategoryNames[subCategory])
+}
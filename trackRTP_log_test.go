@@ -0,0 +1,80 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+
+	type test struct {
+		name string
+		seq  uint16
+		want string
+	}
+
+	tests := []test{
+		{"init", 10, `level=DEBUG msg=init seq=10 position=Init len=1`},
+		{"next", 11, `level=DEBUG msg=aheadWindow seq=11 max=11 min=10 len=2 diff=1 position=Ahead category=Window subCategory=Next`},
+		{"reordered", 9, `level=DEBUG msg=behindWindow seq=9 max=11 len=3 diff=2 position=Behind category=Window subCategory=Reordered`},
+		{"buffer", 30, `level=DEBUG msg=categoryBuffer seq=30 max=11 len=3 position=Ahead category=Buffer`},
+		{"restart", 1000, `level=DEBUG msg=categoryRestart seq=1000 max=11 len=3 position=Ahead category=Restart`},
+	}
+
+	var buf bytes.Buffer
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}
+
+	tr, err := NewWithConfig(DefaultConfig(), WithAheadWindow(10), WithAheadBuffer(10),
+		WithLogger(slog.New(slog.NewTextHandler(&buf, opts))))
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+
+	for i, tc := range tests {
+
+		buf.Reset()
+
+		_, err := tr.PacketArrival(tc.seq)
+		if err != nil {
+			t.Fatalf("%s, test:%d %s PacketArrival err:%v", t.Name(), i, tc.name, err)
+		}
+
+		found := false
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if line == tc.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s, test:%d %s log:\n%s\nmissing:\n%s", t.Name(), i, tc.name, buf.String(), tc.want)
+		}
+	}
+
+	// the default logger discards, without allocating
+	tr.SetLogger(nil)
+	buf.Reset()
+	allocs := testing.AllocsPerRun(100, func() {
+		tr.debug("discarded", seqAttr(1), maxAttr(2), minAttr(3), lenAttr(4), diffAttr(5),
+			positionAttr(PositionAhead), categoryAttr(CategoryWindow), subCategoryAttr(SubCategoryNext))
+	})
+	if allocs != 0 {
+		t.Fatalf("%s, discarded debug allocs:%f != 0", t.Name(), allocs)
+	}
+	if _, err := tr.PacketArrival(1001); err != nil || buf.Len() != 0 {
+		t.Fatalf("%s, discarded err:%v log:%q", t.Name(), err, buf.String())
+	}
+}
@@ -0,0 +1,281 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Manager tracks many RTP streams, with a Tracker per stream
+//
+// Streams are keyed by SSRC, and optionally by the 5-tuple, and the
+// Trackers are created lazily on the first packet of each stream.
+// Idle streams are removed by Expire().
+//
+// Manager is not safe for concurrent use, but with ManagerConfig.Safe each
+// Tracker is wrapped in a SafeTracker, so the stats can be read by other
+// goroutines, like a metrics scrape.
+
+import (
+	"errors"
+	"log/slog"
+	"net/netip"
+	"time"
+
+	"github.com/randomizedcoder/goTrackRTP/rtp"
+)
+
+var (
+	ErrMaxStreams = errors.New("ErrMaxStreams maximum number of streams reached")
+)
+
+// FiveTuple is the IP 5-tuple of a stream
+type FiveTuple struct {
+	Src   netip.AddrPort
+	Dst   netip.AddrPort
+	Proto uint8
+}
+
+// StreamKey identifies a stream
+// FiveTuple is the zero value unless ManagerConfig.KeyByFiveTuple is set
+type StreamKey struct {
+	SSRC      uint32
+	FiveTuple FiveTuple
+}
+
+// ManagerConfig is the template configuration for the Trackers, and the
+// Manager limits
+type ManagerConfig struct {
+	AW         uint16 // ahead window
+	BW         uint16 // behind window
+	AB         uint16 // ahead buffer
+	BB         uint16 // behind buffer
+	Degree     int
+	DebugLevel int
+	Logger     *slog.Logger // if set, each Tracker logs with the ssrc attribute
+
+	Storage       int     // StorageBTree, or StorageBitmap
+	LateThreshold *uint16 // nil is LateThresholdCst
+
+	// Callbacks are shared by all the Trackers, see Callbacks
+	Callbacks Callbacks
+
+	// Time, if any field is set, is used instead of the packet windows
+	Time TimeWindows
+
+	KeyByFiveTuple bool          // include the 5-tuple in the StreamKey
+	Safe           bool          // wrap each Tracker in a SafeTracker, see Stream.Safe
+	IdleTimeout    time.Duration // streams idle longer than this are expired, zero (0) disables
+	MaxStreams     int           // maximum number of streams, zero (0) is unlimited
+
+	// OnExpire, if set, is invoked with each stream removed by Expire(),
+	// including to make room for a new stream, so the final stats can be
+	// recorded
+	OnExpire func(s *Stream)
+}
+
+// Stream is a single tracked stream
+type Stream struct {
+	Key     StreamKey
+	Tracker *Tracker
+	// Safe is set if ManagerConfig.Safe, and then the Tracker must only be
+	// used through it
+	Safe      *SafeTracker
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Manager holds the Trackers for many streams
+type Manager struct {
+	config  ManagerConfig
+	streams map[StreamKey]*Stream
+}
+
+// NewManager creates a Manager
+// The template configuration is validated now, so creating the
+// Trackers later will not fail
+func NewManager(config ManagerConfig) (*Manager, error) {
+
+	if config.Degree == 0 {
+		config.Degree = BtreeDegreeCst
+	}
+
+	var err error
+	if config.Time.isSet() {
+		err = validateTimeWindows(config.Time)
+		if err == nil && config.LateThreshold != nil {
+			// the time windows start at the initial rate
+			tw := timeWindows{config: config.Time, rate: config.Time.Rate}
+			if tw.rate == 0 {
+				tw.rate = TimeWindowRateCst
+			}
+			err = validateLateThreshold(*config.LateThreshold, tw.windowPackets(config.Time.BW))
+		}
+		err = errors.Join(err, validateDegree(config.Degree), validateStorage(config.Storage))
+	} else {
+		err = errors.Join(validateNew(config.AW, config.BW, config.AB, config.BB, config.Degree),
+			validateStorage(config.Storage))
+		if err == nil && config.LateThreshold != nil {
+			err = validateLateThreshold(*config.LateThreshold, config.BW)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		config:  config,
+		streams: make(map[StreamKey]*Stream),
+	}, nil
+}
+
+// Key returns the StreamKey for the SSRC and 5-tuple, honoring KeyByFiveTuple
+func (m *Manager) Key(ssrc uint32, ft FiveTuple) StreamKey {
+
+	key := StreamKey{SSRC: ssrc}
+	if m.config.KeyByFiveTuple {
+		key.FiveTuple = ft
+	}
+
+	return key
+}
+
+// PacketArrival classifies the sequence number using the stream's Tracker,
+// creating the Tracker if this is a new stream
+func (m *Manager) PacketArrival(key StreamKey, seq uint16, now time.Time) (*Taxonomy, error) {
+
+	s, err := m.stream(key, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.LastSeen = now
+
+	if s.Safe != nil {
+		return s.Safe.PacketArrivalAt(seq, now)
+	}
+
+	return s.Tracker.PacketArrivalAt(seq, now)
+}
+
+// ProcessPacket parses the RTP header in buf, and classifies the sequence
+// number using the Tracker for the packet's SSRC ( and 5-tuple )
+func (m *Manager) ProcessPacket(buf []byte, ft FiveTuple, now time.Time) (*Taxonomy, error) {
+
+	var p rtp.Packet
+
+	err := p.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.PacketArrival(m.Key(p.SSRC, ft), p.SequenceNumber, now)
+}
+
+// stream returns the existing stream, or creates a new one
+// If MaxStreams is reached, idle streams are expired first
+func (m *Manager) stream(key StreamKey, now time.Time) (*Stream, error) {
+
+	s, ok := m.streams[key]
+	if ok {
+		return s, nil
+	}
+
+	if m.config.MaxStreams > 0 && len(m.streams) >= m.config.MaxStreams {
+		m.Expire(now)
+		if len(m.streams) >= m.config.MaxStreams {
+			return nil, ErrMaxStreams
+		}
+	}
+
+	tr, err := m.newTracker(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &Stream{
+		Key:       key,
+		Tracker:   tr,
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	if m.config.Safe {
+		s.Safe = NewSafe(tr)
+	}
+	m.streams[key] = s
+
+	return s, nil
+}
+
+// newTracker creates a Tracker from the template configuration
+func (m *Manager) newTracker(key StreamKey) (*Tracker, error) {
+
+	var tr *Tracker
+	var err error
+	if m.config.Time.isSet() {
+		tr, err = newTimeTracker(m.config.Time, m.config.Storage, m.config.Degree, m.config.DebugLevel)
+	} else {
+		tr, err = newTracker(m.config.AW, m.config.BW, m.config.AB, m.config.BB, m.config.Storage, m.config.Degree, m.config.DebugLevel)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if m.config.LateThreshold != nil {
+		err = tr.SetLateThreshold(*m.config.LateThreshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if m.config.Logger != nil {
+		tr.SetLogger(m.config.Logger.With(slog.Uint64("ssrc", uint64(key.SSRC))))
+	}
+
+	tr.SetCallbacks(m.config.Callbacks)
+
+	return tr, nil
+}
+
+// Stream returns the stream for the key
+func (m *Manager) Stream(key StreamKey) (*Stream, bool) {
+	s, ok := m.streams[key]
+	return s, ok
+}
+
+// Remove stops tracking the stream
+func (m *Manager) Remove(key StreamKey) {
+	delete(m.streams, key)
+}
+
+// Expire removes the streams which have been idle for longer than the
+// IdleTimeout, returning the number removed
+func (m *Manager) Expire(now time.Time) (expired int) {
+
+	if m.config.IdleTimeout <= 0 {
+		return 0
+	}
+
+	for key, s := range m.streams {
+		if now.Sub(s.LastSeen) > m.config.IdleTimeout {
+			delete(m.streams, key)
+			expired++
+			if m.config.OnExpire != nil {
+				m.config.OnExpire(s)
+			}
+		}
+	}
+
+	return expired
+}
+
+// Len returns the number of streams being tracked
+func (m *Manager) Len() int {
+	return len(m.streams)
+}
+
+// Range calls f for each stream, until f returns false
+// The order is not specified
+func (m *Manager) Range(f func(s *Stream) bool) {
+	for _, s := range m.streams {
+		if !f(s) {
+			return
+		}
+	}
+}
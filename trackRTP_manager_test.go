@@ -0,0 +1,210 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+
+	type arrival struct {
+		ssrc   uint32
+		port   uint16
+		seq    uint16
+		offset time.Duration
+		err    error
+	}
+
+	type test struct {
+		name           string
+		keyByFiveTuple bool
+		idleTimeout    time.Duration
+		maxStreams     int
+		arrivals       []arrival
+		expireOffset   time.Duration
+		Expired        int
+		Len            int
+		OnExpire       int // including the streams expired to make room
+	}
+
+	tests := []test{
+		{"single stream", false, 0, 0,
+			[]arrival{{1, 5000, 0, 0, nil}, {1, 5000, 1, 0, nil}},
+			0, 0, 1, 0},
+		{"ssrc only ignores ports", false, 0, 0,
+			[]arrival{{1, 5000, 0, 0, nil}, {1, 5002, 1, 0, nil}},
+			0, 0, 1, 0},
+		{"five tuple", true, 0, 0,
+			[]arrival{{1, 5000, 0, 0, nil}, {1, 5002, 1, 0, nil}},
+			0, 0, 2, 0},
+		{"max streams", false, 0, 2,
+			[]arrival{{1, 5000, 0, 0, nil}, {2, 5000, 0, 0, nil}, {3, 5000, 0, 0, ErrMaxStreams}, {1, 5000, 1, 0, nil}},
+			0, 0, 2, 0},
+		{"max streams expires idle", false, time.Second, 2,
+			[]arrival{{1, 5000, 0, 0, nil}, {2, 5000, 0, 2 * time.Second, nil}, {3, 5000, 0, 2 * time.Second, nil}},
+			2 * time.Second, 0, 2, 1},
+		{"expire", false, time.Second, 0,
+			[]arrival{{1, 5000, 0, 0, nil}, {2, 5000, 0, 0, nil}, {2, 5000, 1, 2 * time.Second, nil}},
+			2 * time.Second, 1, 1, 1},
+		{"expire disabled", false, 0, 0,
+			[]arrival{{1, 5000, 0, 0, nil}, {2, 5000, 0, 0, nil}},
+			time.Hour, 0, 2, 0},
+	}
+
+	start := time.Unix(0, 0)
+	dst := netip.MustParseAddrPort("192.0.2.1:6000")
+
+	for i, tc := range tests {
+
+		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc.name)
+
+		var onExpire int
+		m, err := NewManager(ManagerConfig{
+			AW: 10, BW: 10, AB: 10, BB: 10,
+			KeyByFiveTuple: tc.keyByFiveTuple,
+			IdleTimeout:    tc.idleTimeout,
+			MaxStreams:     tc.maxStreams,
+			OnExpire: func(s *Stream) {
+				if s.Tracker.Stats().Packets == 0 {
+					t.Fatalf("%s, test:%d OnExpire stream without stats", t.Name(), i)
+				}
+				onExpire++
+			},
+		})
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+
+		for j, a := range tc.arrivals {
+			ft := FiveTuple{
+				Src:   netip.AddrPortFrom(netip.MustParseAddr("192.0.2.2"), a.port),
+				Dst:   dst,
+				Proto: 17,
+			}
+			_, e := m.PacketArrival(m.Key(a.ssrc, ft), a.seq, start.Add(a.offset))
+			if !errors.Is(e, a.err) {
+				t.Fatalf("%s, test:%d arrival:%d err:%v != a.err:%v", t.Name(), i, j, e, a.err)
+			}
+		}
+
+		expired := m.Expire(start.Add(tc.expireOffset))
+		if !reflect.DeepEqual(expired, tc.Expired) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(expired:%v, tc.Expired:%v)", t.Name(), i, expired, tc.Expired)
+		}
+
+		if onExpire != tc.OnExpire {
+			t.Fatalf("%s, test:%d onExpire:%d != tc.OnExpire:%d", t.Name(), i, onExpire, tc.OnExpire)
+		}
+
+		if !reflect.DeepEqual(m.Len(), tc.Len) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(m.Len():%v, tc.Len:%v)", t.Name(), i, m.Len(), tc.Len)
+		}
+
+		var ranged int
+		var packets uint64
+		m.Range(func(s *Stream) bool {
+			ranged++
+			st := s.Tracker.Stats()
+			packets += st.Packets
+			return true
+		})
+		if !reflect.DeepEqual(ranged, tc.Len) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(ranged:%v, tc.Len:%v)", t.Name(), i, ranged, tc.Len)
+		}
+		if packets == 0 && tc.Len > 0 {
+			t.Fatalf("%s, test:%d no packets counted in the stream stats", t.Name(), i)
+		}
+	}
+}
+
+func TestManagerConfig(t *testing.T) {
+
+	_, err := NewManager(ManagerConfig{AW: 1, BW: 10, AB: 10, BB: 10})
+	if !errors.Is(err, ErrWindowAWMin) {
+		t.Fatalf("%s, err:%v != ErrWindowAWMin", t.Name(), err)
+	}
+
+	lt0, lt11 := uint16(0), uint16(11)
+	invalid := []struct {
+		name   string
+		config ManagerConfig
+		err    error
+	}{
+		{"storage", ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10, Storage: StorageBitmap + 1}, ErrStorage},
+		{"late threshold", ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10, LateThreshold: &lt11}, ErrLateThresholdMax},
+		{"time degree", ManagerConfig{Time: TimeWindows{AW: time.Second, BW: time.Second, AB: time.Second, BB: time.Second},
+			Degree: MaxDegree + 1}, ErrWindowDegreeMax},
+		{"time late threshold", ManagerConfig{Time: TimeWindows{AW: time.Second, BW: 100 * time.Millisecond, AB: time.Second,
+			BB: time.Second, Rate: 100}, LateThreshold: &lt11}, ErrLateThresholdMax},
+		{"time partial", ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10, Time: TimeWindows{AW: time.Second}}, ErrTimeWindowBW},
+	}
+	for i, tc := range invalid {
+		_, err := NewManager(tc.config)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+	}
+
+	// the Trackers use the storage, late threshold, and callbacks
+	var dups int
+	m, err := NewManager(ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10, Storage: StorageBitmap, LateThreshold: &lt0,
+		Callbacks: Callbacks{OnDuplicate: func(seq uint16, tax *Taxonomy) { dups++ }}})
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+	for _, seq := range []uint16{1, 1} {
+		_, err = m.PacketArrival(m.Key(1, FiveTuple{}), seq, time.Now())
+		if err != nil {
+			t.Fatalf("%s, PacketArrival err:%v", t.Name(), err)
+		}
+	}
+	s, _ := m.Stream(m.Key(1, FiveTuple{}))
+	if _, ok := s.Tracker.b.(*bitmapStorage); !ok {
+		t.Fatalf("%s, storage:%T != *bitmapStorage", t.Name(), s.Tracker.b)
+	}
+	if s.Tracker.lt != 0 {
+		t.Fatalf("%s, lt:%d != 0", t.Name(), s.Tracker.lt)
+	}
+	if dups != 1 {
+		t.Fatalf("%s, dups:%d != 1", t.Name(), dups)
+	}
+
+	// the Trackers are wrapped in SafeTrackers
+	m, err = NewManager(ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10, Safe: true})
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+	_, err = m.PacketArrival(m.Key(1, FiveTuple{}), 1, time.Now())
+	if err != nil {
+		t.Fatalf("%s, PacketArrival err:%v", t.Name(), err)
+	}
+	s, _ = m.Stream(m.Key(1, FiveTuple{}))
+	if s.Safe == nil || s.Safe.Stats().Packets != 1 {
+		t.Fatalf("%s, Safe:%v not counting the arrivals", t.Name(), s.Safe)
+	}
+
+	// each stream logs with its ssrc
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	m, err = NewManager(ManagerConfig{AW: 10, BW: 10, AB: 10, BB: 10, Logger: logger})
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+	_, err = m.PacketArrival(m.Key(1234, FiveTuple{}), 1, time.Now())
+	if err != nil {
+		t.Fatalf("%s, PacketArrival err:%v", t.Name(), err)
+	}
+	if !strings.Contains(buf.String(), "ssrc=1234 seq=1") {
+		t.Fatalf("%s, log:%q missing ssrc=1234 seq=1", t.Name(), buf.String())
+	}
+}
@@ -28,6 +28,12 @@ func isLess[T uint16](s1, s2 uint16) bool {
 	return b
 }
 
+// IsLess is the wrap aware s1 < s2, for sequence numbers within 2^15 of each other
+// It is the ordering used by the Tracker, for use by the subpackages
+func IsLess(s1, s2 uint16) bool {
+	return isLessBranchless(s1, s2)
+}
+
 // isLessBranchless is a non-banching (if-ess) version to find less that handles sequence wrapping
 func isLessBranchless(s1, s2 uint16) bool {
 
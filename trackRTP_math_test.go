@@ -90,6 +90,11 @@ func TestIsLess(t *testing.T) {
 		if !reflect.DeepEqual(tc.want, got) {
 			t.Fatalf("test: %d, expected: %v, got: %v", i, tc.want, got)
 		}
+
+		// the exported IsLess is the same ordering
+		if IsLess(tc.seq, tc.m) != got {
+			t.Fatalf("test: %d, IsLess:%v != isLess:%v", i, IsLess(tc.seq, tc.m), got)
+		}
 	}
 }
 
@@ -0,0 +1,185 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// SMPTE ST 2022-7 seamless protection switching
+//
+// The same stream is sent over two paths ( legs ), and the receiver
+// merges them, using whichever copy of each packet arrives first.  A
+// packet is only lost from the merged stream if it was lost on both legs.
+//
+// Merger tracks each leg with its own Tracker, so the loss of each path is
+// visible, and also tracks the merged stream with a third Tracker.  The
+// first copy of a packet is new to the merged Tracker, and the second copy
+// is a duplicate, so the merged duplicates are the normal redundancy.
+//
+// The skew is the difference between the Max() of the legs, in packets.
+// The copies of a packet must arrive within the merged windows, so the
+// behind window plus buffer needs to cover the largest expected skew.
+//
+// Merger is not safe for concurrent use.
+
+import (
+	"errors"
+
+	"github.com/randomizedcoder/goTrackRTP/rtp"
+)
+
+// Leg
+const (
+	LegA int = iota
+	LegB
+	LegCount
+)
+
+var (
+	ErrLeg = errors.New("ErrLeg unknown leg")
+)
+
+// MergeTaxonomy is the classification of a packet on its leg, and in the
+// merged stream
+type MergeTaxonomy struct {
+	Leg    int
+	First  bool // this leg supplied the packet to the merged stream
+	Tax    *Taxonomy
+	Merged *Taxonomy
+}
+
+// MergerStats is a snapshot of the Merger statistics
+type MergerStats struct {
+	Legs     [LegCount]Stats
+	Merged   Stats
+	Supplied [LegCount]uint64 // packets supplied to the merged stream by each leg
+	Skew     int              // Max() of leg A minus leg B, in packets
+	MaxSkew  int              // largest absolute skew
+}
+
+// Merger tracks two redundant legs, and the merged stream
+type Merger struct {
+	legs   [LegCount]*Tracker
+	merged *Tracker
+
+	supplied [LegCount]uint64
+	started  [LegCount]bool
+	skew     int
+	maxSkew  int
+}
+
+// NewMerger creates a Merger, with the same windows for both legs and the merged stream
+func NewMerger(aw uint16, bw uint16, ab uint16, bb uint16, debugLevel int) (*Merger, error) {
+
+	m := &Merger{}
+
+	for i := range m.legs {
+		t, err := New(aw, bw, ab, bb, debugLevel)
+		if err != nil {
+			return nil, err
+		}
+		m.legs[i] = t
+	}
+
+	t, err := New(aw, bw, ab, bb, debugLevel)
+	if err != nil {
+		return nil, err
+	}
+	m.merged = t
+
+	return m, nil
+}
+
+// PacketArrival classifies the sequence number on the leg, and in the merged stream
+func (m *Merger) PacketArrival(leg int, seq uint16) (*MergeTaxonomy, error) {
+
+	if leg < 0 || leg >= LegCount {
+		return nil, ErrLeg
+	}
+
+	tax, err := m.legs[leg].PacketArrival(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, err := m.merged.PacketArrival(seq)
+	if err != nil {
+		return nil, err
+	}
+
+	mt := &MergeTaxonomy{
+		Leg:    leg,
+		First:  first(merged),
+		Tax:    tax,
+		Merged: merged,
+	}
+
+	if mt.First {
+		m.supplied[leg]++
+	}
+
+	m.started[leg] = true
+	if m.started[LegA] && m.started[LegB] {
+		m.skew = int(int16(m.legs[LegA].Max() - m.legs[LegB].Max()))
+		abs := m.skew
+		if abs < 0 {
+			abs = -abs
+		}
+		if abs > m.maxSkew {
+			m.maxSkew = abs
+		}
+	}
+
+	return mt, nil
+}
+
+// first returns true if the packet is new to the merged stream
+// Packets in the safety buffers are not used
+func first(tax *Taxonomy) bool {
+	return tax.Position != PositionDuplicate &&
+		tax.SubCategory != SubCategoryDuplicate &&
+		tax.Categroy != CategoryBuffer
+}
+
+// ProcessPacket parses the RTP header in buf, and then classifies the sequence number
+func (m *Merger) ProcessPacket(leg int, buf []byte) (*MergeTaxonomy, error) {
+
+	var p rtp.Packet
+
+	err := p.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.PacketArrival(leg, p.SequenceNumber)
+}
+
+// Leg returns the Tracker for the leg, or nil if the leg is unknown
+func (m *Merger) Leg(leg int) *Tracker {
+	if leg < 0 || leg >= LegCount {
+		return nil
+	}
+	return m.legs[leg]
+}
+
+// Merged returns the Tracker for the merged stream
+func (m *Merger) Merged() *Tracker {
+	return m.merged
+}
+
+// Skew returns Max() of leg A minus leg B, in packets
+// Positive means leg A is ahead, and it's zero (0) until both legs have packets
+func (m *Merger) Skew() int {
+	return m.skew
+}
+
+// Stats returns a snapshot of the per leg, and merged, statistics
+func (m *Merger) Stats() (s MergerStats) {
+
+	for i := range m.legs {
+		s.Legs[i] = m.legs[i].Stats()
+	}
+	s.Merged = m.merged.Stats()
+	s.Supplied = m.supplied
+	s.Skew = m.skew
+	s.MaxSkew = m.maxSkew
+
+	return s
+}
@@ -0,0 +1,91 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerger(t *testing.T) {
+
+	type test struct {
+		leg   int
+		seq   uint16
+		first bool
+		skew  int
+	}
+
+	// leg B is behind, and loses 3, while leg A loses 5, and both lose 8
+	tests := []test{
+		{LegA, 0, true, 0},
+		{LegA, 1, true, 0},
+		{LegB, 0, false, 1},
+		{LegA, 2, true, 2},
+		{LegB, 1, false, 1},
+		{LegA, 3, true, 2},
+		{LegB, 2, false, 1},
+		{LegA, 4, true, 2},
+		{LegA, 6, true, 4},
+		{LegB, 4, false, 2},
+		{LegB, 5, true, 1},
+		{LegA, 7, true, 2},
+		{LegB, 6, false, 1},
+		{LegB, 7, false, 0},
+		{LegB, 9, true, -2},
+		{LegA, 9, false, 0},
+	}
+
+	m, err := NewMerger(10, 10, 10, 10, debugLevelCst)
+	if err != nil {
+		t.Fatalf("%s, NewMerger err:%v", t.Name(), err)
+	}
+
+	for i, tc := range tests {
+
+		mt, e := m.PacketArrival(tc.leg, tc.seq)
+		if e != nil {
+			t.Fatalf("%s, test:%d err != nil:%v", t.Name(), i, e)
+		}
+
+		if mt.Leg != tc.leg {
+			t.Fatalf("%s, test:%d mt.Leg:%d != tc.leg:%d", t.Name(), i, mt.Leg, tc.leg)
+		}
+		if mt.First != tc.first {
+			t.Fatalf("%s, test:%d seq:%d mt.First:%t != tc.first:%t", t.Name(), i, tc.seq, mt.First, tc.first)
+		}
+		if m.Skew() != tc.skew {
+			t.Fatalf("%s, test:%d seq:%d Skew():%d != tc.skew:%d", t.Name(), i, tc.seq, m.Skew(), tc.skew)
+		}
+	}
+
+	if missing := m.Leg(LegA).Missing(); !reflect.DeepEqual(missing, []uint16{5, 8}) {
+		t.Fatalf("%s, leg A Missing():%v", t.Name(), missing)
+	}
+	if missing := m.Leg(LegB).Missing(); !reflect.DeepEqual(missing, []uint16{3, 8}) {
+		t.Fatalf("%s, leg B Missing():%v", t.Name(), missing)
+	}
+	if missing := m.Merged().Missing(); !reflect.DeepEqual(missing, []uint16{8}) {
+		t.Fatalf("%s, merged Missing():%v", t.Name(), missing)
+	}
+
+	s := m.Stats()
+	if s.Supplied != [LegCount]uint64{7, 2} {
+		t.Fatalf("%s, Supplied:%v", t.Name(), s.Supplied)
+	}
+	if s.MaxSkew != 4 {
+		t.Fatalf("%s, MaxSkew:%d != 4", t.Name(), s.MaxSkew)
+	}
+	if s.Merged.Packets != uint64(len(tests)) || s.Legs[LegA].Packets+s.Legs[LegB].Packets != uint64(len(tests)) {
+		t.Fatalf("%s, Packets merged:%d A:%d B:%d", t.Name(), s.Merged.Packets, s.Legs[LegA].Packets, s.Legs[LegB].Packets)
+	}
+
+	if _, e := m.PacketArrival(LegCount, 10); e != ErrLeg {
+		t.Fatalf("%s, PacketArrival(LegCount) err:%v != ErrLeg", t.Name(), e)
+	}
+	if m.Leg(-1) != nil {
+		t.Fatalf("%s, Leg(-1) != nil", t.Name())
+	}
+}
@@ -0,0 +1,54 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Missing sequence numbers
+//
+// The btree only holds the sequence numbers we have received, so the
+// missing sequence numbers are the gaps between the items.
+// The btree is ordered using isLess, so Ascend() walks the items in
+// sequence order, correctly handling the 2^16 wrap.
+
+// SeqRange is an inclusive range of sequence numbers [Start, End]
+// Start may be numerically greater than End if the range wraps
+type SeqRange struct {
+	Start uint16
+	End   uint16
+}
+
+// Len returns the number of sequence numbers in the range
+func (r SeqRange) Len() int {
+	return int(r.End-r.Start) + 1
+}
+
+// MissingRanges() returns the gaps between Min() and Max() as ranges
+// Try not to use this function frequently ( expensive )
+func (t *Tracker) MissingRanges() (ranges []SeqRange) {
+
+	var prev uint16
+	first := true
+
+	t.b.Ascend(func(item uint16) bool {
+		if !first && item-prev > 1 {
+			ranges = append(ranges, SeqRange{Start: prev + 1, End: item - 1})
+		}
+		prev = item
+		first = false
+		return true
+	})
+
+	return ranges
+}
+
+// Missing() returns the list of missing sequence numbers between Min() and Max()
+// Try not to use this function frequently ( expensive )
+func (t *Tracker) Missing() (missing []uint16) {
+
+	for _, r := range t.MissingRanges() {
+		for i, s := 0, r.Start; i < r.Len(); i, s = i+1, s+1 {
+			missing = append(missing, s)
+		}
+	}
+
+	return missing
+}
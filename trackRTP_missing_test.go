@@ -0,0 +1,68 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissing(t *testing.T) {
+
+	type test struct {
+		aw      uint16
+		bw      uint16
+		ab      uint16
+		bb      uint16
+		seqs    []uint16
+		Ranges  []SeqRange
+		Missing []uint16
+	}
+
+	tests := []test{
+		// nothing missing
+		{10, 10, 10, 10, []uint16{0}, nil, nil},
+		{10, 10, 10, 10, []uint16{0, 1, 2, 3}, nil, nil},
+		// single gaps
+		{10, 10, 10, 10, []uint16{0, 2}, []SeqRange{{1, 1}}, []uint16{1}},
+		{10, 10, 10, 10, []uint16{0, 1, 5}, []SeqRange{{2, 4}}, []uint16{2, 3, 4}},
+		// multiple gaps
+		{10, 10, 10, 10, []uint16{0, 2, 5}, []SeqRange{{1, 1}, {3, 4}}, []uint16{1, 3, 4}},
+		// behind packet fills part of a gap
+		{10, 10, 10, 10, []uint16{0, 5, 3}, []SeqRange{{1, 2}, {4, 4}}, []uint16{1, 2, 4}},
+		// wrap
+		{10, 10, 10, 10, []uint16{maxUint16 - 1, 1}, []SeqRange{{maxUint16, 0}}, []uint16{maxUint16, 0}},
+		{10, 10, 10, 10, []uint16{maxUint16 - 2, maxUint16, 2}, []SeqRange{{maxUint16 - 1, maxUint16 - 1}, {0, 1}}, []uint16{maxUint16 - 1, 0, 1}},
+		// gaps falling off the back of the window are no longer reported
+		{5, 5, 10, 10, []uint16{0, 2, 4, 6, 8, 10, 12}, []SeqRange{{5, 5}, {7, 7}, {9, 9}, {11, 11}}, []uint16{5, 7, 9, 11}},
+	}
+
+	for _, storage := range testStorages {
+		for i, tc := range tests {
+
+			t.Logf("%s storage:%d, i:%d, tc: %v\n", t.Name(), storage, i, tc)
+
+			tr, err := NewWithStorage(tc.aw, tc.bw, tc.ab, tc.bb, storage, debugLevelCst)
+			if err != nil {
+				t.Fatalf("%s, err:%v", t.Name(), err)
+			}
+
+			for _, seq := range tc.seqs {
+				_, e := tr.PacketArrival(seq)
+				if e != nil {
+					t.Fatalf("%s, err != nil:%v", t.Name(), e)
+				}
+			}
+
+			if !reflect.DeepEqual(tr.MissingRanges(), tc.Ranges) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tr.MissingRanges():%v, tc.Ranges:%v)", t.Name(), i, tr.MissingRanges(), tc.Ranges)
+			}
+
+			if !reflect.DeepEqual(tr.Missing(), tc.Missing) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tr.Missing():%v, tc.Missing:%v)", t.Name(), i, tr.Missing(), tc.Missing)
+			}
+		}
+	}
+}
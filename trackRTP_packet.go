@@ -0,0 +1,24 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Convenience for callers with the raw RTP packet, rather than the sequence number
+
+import (
+	"github.com/randomizedcoder/goTrackRTP/rtp"
+)
+
+// ProcessPacket parses the RTP header in buf, and then classifies the sequence number
+// Malformed packets return a *rtp.ParseError, which can be matched using
+// errors.Is with the rtp.Err* errors
+func (t *Tracker) ProcessPacket(buf []byte) (*Taxonomy, error) {
+
+	var p rtp.Packet
+
+	err := p.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.PacketArrival(p.SequenceNumber)
+}
@@ -0,0 +1,126 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// RFC 3550 Appendix A.1 RTP Data Header Validity Checks
+//
+// The Tracker classifies packets relative to a window, which doesn't know
+// about the 2^16 sequence number cycles.  The RTCP receiver report needs
+// the extended highest sequence number, and the expected and received
+// counts, so this keeps the RFC 3550 state alongside the window.
+//
+// https://www.rfc-editor.org/rfc/rfc3550#appendix-A.1
+
+const (
+	RFC3550MaxDropoutCst    = 3000
+	RFC3550MaxMisorderCst   = 100
+	RFC3550MinSequentialCst = 2
+
+	rtpSeqMod = 1 << 16
+)
+
+// RFC3550 is the per source state from RFC 3550 Appendix A.1
+type RFC3550 struct {
+	MaxSeq    uint16 // highest seq. number seen
+	Cycles    uint32 // count of seq. number cycles ( 2^16 wraps )
+	BaseSeq   uint32 // base seq number
+	BadSeq    uint32 // last 'bad' seq number + 1
+	Probation int    // sequ. packets till source is valid
+	Received  uint64 // packets received
+
+	initialized bool
+}
+
+// initSeq is init_seq() from RFC 3550 Appendix A.1
+func (s *RFC3550) initSeq(seq uint16) {
+	s.BaseSeq = uint32(seq)
+	s.MaxSeq = seq
+	s.BadSeq = rtpSeqMod + 1 // so seq == bad_seq is false
+	s.Cycles = 0
+	s.Received = 0
+}
+
+// update is update_seq() from RFC 3550 Appendix A.1
+// Returns false if the packet is not (yet) considered valid
+func (s *RFC3550) update(seq uint16) bool {
+
+	if !s.initialized {
+		s.initSeq(seq)
+		s.MaxSeq = seq - 1
+		s.Probation = RFC3550MinSequentialCst
+		s.initialized = true
+	}
+
+	udelta := seq - s.MaxSeq
+
+	if s.Probation > 0 {
+		// packet is in sequence
+		if seq == s.MaxSeq+1 {
+			s.Probation--
+			s.MaxSeq = seq
+			if s.Probation == 0 {
+				s.initSeq(seq)
+				s.Received++
+				return true
+			}
+		} else {
+			s.Probation = RFC3550MinSequentialCst - 1
+			s.MaxSeq = seq
+		}
+		return false
+	} else if udelta < RFC3550MaxDropoutCst {
+		// in order, with permissible gap
+		if seq < s.MaxSeq {
+			// Sequence number wrapped - count another 64K cycle
+			s.Cycles++
+		}
+		s.MaxSeq = seq
+	} else if udelta <= rtpSeqMod-RFC3550MaxMisorderCst {
+		// the sequence number made a very large jump
+		if uint32(seq) == s.BadSeq {
+			// Two sequential packets -- assume that the other side
+			// restarted without telling us so just re-sync
+			// (i.e., pretend this was the first packet).
+			s.initSeq(seq)
+		} else {
+			s.BadSeq = uint32(seq+1) & (rtpSeqMod - 1)
+			return false
+		}
+	}
+	// else duplicate or reordered packet
+
+	s.Received++
+
+	return true
+}
+
+// Valid returns true once the source has passed probation
+func (s *RFC3550) Valid() bool {
+	return s.initialized && s.Probation == 0
+}
+
+// ExtendedMax returns the extended highest sequence number received
+// The low 16 bits are the highest sequence number, and the high 16 bits
+// are the count of sequence number cycles
+func (s *RFC3550) ExtendedMax() uint32 {
+	return s.Cycles<<16 + uint32(s.MaxSeq)
+}
+
+// Expected returns the number of packets expected
+func (s *RFC3550) Expected() int64 {
+	if !s.Valid() {
+		return 0
+	}
+	return int64(s.ExtendedMax()) - int64(s.BaseSeq) + 1
+}
+
+// Lost returns the cumulative number of packets lost
+// This can be negative if there are duplicates
+func (s *RFC3550) Lost() int64 {
+	return s.Expected() - int64(s.Received)
+}
+
+// RFC3550() returns a copy of the RFC 3550 Appendix A.1 source state
+func (t *Tracker) RFC3550() RFC3550 {
+	return t.rfc
+}
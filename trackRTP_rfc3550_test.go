@@ -0,0 +1,90 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRFC3550(t *testing.T) {
+
+	type test struct {
+		seqs        []uint16
+		Valid       bool
+		Probation   int
+		Cycles      uint32
+		BaseSeq     uint32
+		ExtendedMax uint32
+		Received    uint64
+		Expected    int64
+		Lost        int64
+	}
+
+	tests := []test{
+		// probation
+		{[]uint16{0}, false, 1, 0, 0, 0, 0, 0, 0},
+		{[]uint16{10, 12}, false, 1, 0, 10, 12, 0, 0, 0},
+		{[]uint16{10, 12, 13}, true, 0, 0, 13, 13, 1, 1, 0},
+		// in sequence
+		{[]uint16{0, 1, 2, 3}, true, 0, 0, 1, 3, 3, 3, 0},
+		// loss
+		{[]uint16{10, 11, 13, 14}, true, 0, 0, 11, 14, 3, 4, 1},
+		// duplicates can make lost negative
+		{[]uint16{10, 11, 12, 12}, true, 0, 0, 11, 12, 3, 2, -1},
+		// reordered
+		{[]uint16{10, 11, 13, 12}, true, 0, 0, 11, 13, 3, 3, 0},
+		// wrap
+		{[]uint16{maxUint16 - 1, maxUint16, 0, 1}, true, 0, 1, uint32(maxUint16), 1<<16 + 1, 3, 3, 0},
+		{[]uint16{maxUint16 - 1, maxUint16, 2}, true, 0, 1, uint32(maxUint16), 1<<16 + 2, 2, 4, 2},
+		// large jump is ignored, unless the next packet follows it
+		{[]uint16{10, 11, 12, 5000}, true, 0, 0, 11, 12, 2, 2, 0},
+		{[]uint16{10, 11, 12, 5000, 5001}, true, 0, 0, 5001, 5001, 1, 1, 0},
+	}
+
+	for i, tc := range tests {
+
+		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
+
+		tr, err := New(10, 10, 10, 10, debugLevelCst)
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+
+		for _, seq := range tc.seqs {
+			_, e := tr.PacketArrival(seq)
+			if e != nil {
+				t.Fatalf("%s, err != nil:%v", t.Name(), e)
+			}
+		}
+
+		r := tr.RFC3550()
+
+		if !reflect.DeepEqual(r.Valid(), tc.Valid) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.Valid():%v, tc.Valid:%v)", t.Name(), i, r.Valid(), tc.Valid)
+		}
+		if !reflect.DeepEqual(r.Probation, tc.Probation) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.Probation:%v, tc.Probation:%v)", t.Name(), i, r.Probation, tc.Probation)
+		}
+		if !reflect.DeepEqual(r.Cycles, tc.Cycles) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.Cycles:%v, tc.Cycles:%v)", t.Name(), i, r.Cycles, tc.Cycles)
+		}
+		if !reflect.DeepEqual(r.BaseSeq, tc.BaseSeq) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.BaseSeq:%v, tc.BaseSeq:%v)", t.Name(), i, r.BaseSeq, tc.BaseSeq)
+		}
+		if !reflect.DeepEqual(r.ExtendedMax(), tc.ExtendedMax) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.ExtendedMax():%v, tc.ExtendedMax:%v)", t.Name(), i, r.ExtendedMax(), tc.ExtendedMax)
+		}
+		if !reflect.DeepEqual(r.Received, tc.Received) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.Received:%v, tc.Received:%v)", t.Name(), i, r.Received, tc.Received)
+		}
+		if !reflect.DeepEqual(r.Expected(), tc.Expected) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.Expected():%v, tc.Expected:%v)", t.Name(), i, r.Expected(), tc.Expected)
+		}
+		if !reflect.DeepEqual(r.Lost(), tc.Lost) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(r.Lost():%v, tc.Lost:%v)", t.Name(), i, r.Lost(), tc.Lost)
+		}
+	}
+}
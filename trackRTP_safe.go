@@ -0,0 +1,271 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// SafeTracker is a concurrency safe Tracker
+//
+// Packet arrivals are serialized with a mutex, and only increment a version
+// counter, so they don't copy, or allocate.  Len(), Max(), Min(), Window(),
+// BehindWindow(), and Stats() read an immutable snapshot, published using an
+// atomic.Pointer, which is only rebuilt, holding the lock, when a read finds
+// the version has changed.  So a metrics scrape takes the lock at most once
+// per scrape, and always sees consistent counters.
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SafeTracker wraps a Tracker for use from multiple goroutines
+type SafeTracker struct {
+	mu sync.Mutex
+	t  *Tracker
+
+	version  atomic.Uint64 // incremented holding the lock, by each change
+	snapshot atomic.Pointer[safeSnapshot]
+}
+
+// safeSnapshot is the published state, which is never modified
+type safeSnapshot struct {
+	version uint64
+	stats   Stats
+	len     int
+	max     uint16
+	min     uint16
+	window  uint16
+	bw      uint16
+}
+
+// NewSafe wraps the Tracker, which must not be used directly afterwards
+func NewSafe(t *Tracker) *SafeTracker {
+
+	return &SafeTracker{
+		t: t,
+	}
+}
+
+// PacketArrival is the concurrency safe Tracker.PacketArrival
+func (s *SafeTracker) PacketArrival(seq uint16) (*Taxonomy, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tax, err := s.t.PacketArrival(seq)
+	if err != nil {
+		return tax, err
+	}
+
+	s.version.Add(1)
+
+	return tax, nil
+}
+
+// PacketArrivalAt is the concurrency safe Tracker.PacketArrivalAt
+func (s *SafeTracker) PacketArrivalAt(seq uint16, now time.Time) (*Taxonomy, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tax, err := s.t.PacketArrivalAt(seq, now)
+	if err != nil {
+		return tax, err
+	}
+
+	s.version.Add(1)
+
+	return tax, nil
+}
+
+// ProcessPacket is the concurrency safe Tracker.ProcessPacket
+func (s *SafeTracker) ProcessPacket(buf []byte) (*Taxonomy, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tax, err := s.t.ProcessPacket(buf)
+	if err != nil {
+		return tax, err
+	}
+
+	s.version.Add(1)
+
+	return tax, nil
+}
+
+// PacketArrivalTimestamp is the concurrency safe Tracker.PacketArrivalTimestamp
+func (s *SafeTracker) PacketArrivalTimestamp(seq uint16, rtpTS uint32, clockRate uint32, arrival time.Time) (*Taxonomy, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tax, err := s.t.PacketArrivalTimestamp(seq, rtpTS, clockRate, arrival)
+	if err != nil {
+		return tax, err
+	}
+
+	s.version.Add(1)
+
+	return tax, nil
+}
+
+// load returns the snapshot, rebuilding it if the version has changed
+func (s *SafeTracker) load() *safeSnapshot {
+
+	snap := s.snapshot.Load()
+	if snap != nil && snap.version == s.version.Load() {
+		return snap
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the version can't change holding the lock, and another reader may
+	// have rebuilt the snapshot already
+	v := s.version.Load()
+	snap = s.snapshot.Load()
+	if snap != nil && snap.version == v {
+		return snap
+	}
+
+	snap = &safeSnapshot{
+		version: v,
+		stats:   s.t.stats,
+		len:     s.t.Len(),
+		max:     s.t.Max(),
+		min:     s.t.Min(),
+		window:  s.t.Window,
+		bw:      s.t.bw,
+	}
+	s.snapshot.Store(snap)
+
+	return snap
+}
+
+// Len() returns the current number of items, from the snapshot
+func (s *SafeTracker) Len() int {
+	return s.load().len
+}
+
+// Max() returns the current max item, from the snapshot
+func (s *SafeTracker) Max() uint16 {
+	return s.load().max
+}
+
+// Min() returns the current min item, from the snapshot
+func (s *SafeTracker) Min() uint16 {
+	return s.load().min
+}
+
+// Window() returns the acceptable window size ( aw + bw ), from the snapshot
+// Time windows change with the packet rate
+func (s *SafeTracker) Window() uint16 {
+	return s.load().window
+}
+
+// BehindWindow() returns the behind window (bw), from the snapshot
+// Time windows change with the packet rate
+func (s *SafeTracker) BehindWindow() uint16 {
+	return s.load().bw
+}
+
+// Stats() returns the cumulative statistics, from the snapshot
+func (s *SafeTracker) Stats() Stats {
+	return s.load().stats
+}
+
+// ResetStats() clears the cumulative statistics
+func (s *SafeTracker) ResetStats() {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.t.ResetStats()
+	s.version.Add(1)
+}
+
+// SetLateThreshold is the concurrency safe Tracker.SetLateThreshold
+func (s *SafeTracker) SetLateThreshold(lt uint16) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.t.SetLateThreshold(lt)
+}
+
+// SetCallbacks is the concurrency safe Tracker.SetCallbacks
+// The callbacks are invoked holding the lock, so must not call the SafeTracker
+func (s *SafeTracker) SetCallbacks(cb Callbacks) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.t.SetCallbacks(cb)
+}
+
+// MissingRanges() is the concurrency safe Tracker.MissingRanges
+func (s *SafeTracker) MissingRanges() []SeqRange {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.t.MissingRanges()
+}
+
+// Missing() is the concurrency safe Tracker.Missing
+func (s *SafeTracker) Missing() []uint16 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.t.Missing()
+}
+
+// RFC3550() is the concurrency safe Tracker.RFC3550
+func (s *SafeTracker) RFC3550() RFC3550 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.t.RFC3550()
+}
+
+// Rate() is the concurrency safe Tracker.Rate
+func (s *SafeTracker) Rate() float64 {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.t.Rate()
+}
+
+// Jitter() is the concurrency safe Tracker.Jitter
+func (s *SafeTracker) Jitter() Jitter {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.t.Jitter()
+}
+
+// Bursts() is the concurrency safe Tracker.Bursts
+func (s *SafeTracker) Bursts() Bursts {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.t.Bursts()
+}
+
+// Locked calls f holding the lock, for access to the rest of the Tracker
+// f must not call the SafeTracker, and the version is incremented
+// afterwards, in case f changed the Tracker
+func (s *SafeTracker) Locked(f func(t *Tracker)) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f(s.t)
+
+	s.version.Add(1)
+}
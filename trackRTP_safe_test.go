@@ -0,0 +1,160 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Run these with the race detector: go test -race -run TestSafe
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSafeTracker(t *testing.T) {
+
+	type test struct {
+		aw      uint16
+		bw      uint16
+		ab      uint16
+		bb      uint16
+		writers int
+		readers int
+		loops   int
+	}
+
+	tests := []test{
+		{10, 10, 10, 10, 1, 1, 1000},
+		{100, 100, 100, 100, 1, 4, 10000},
+		{100, 100, 100, 100, 4, 4, 10000},
+	}
+
+	for i, tc := range tests {
+
+		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
+
+		tr, err := New(tc.aw, tc.bw, tc.ab, tc.bb, 0)
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+		s := NewSafe(tr)
+
+		done := make(chan struct{})
+
+		var readers sync.WaitGroup
+		for r := 0; r < tc.readers; r++ {
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					_ = s.Len()
+					_ = s.Max()
+					_ = s.Min()
+					st := s.Stats()
+					_ = st.Duplicates()
+					// the snapshot is consistent, so every packet is classified
+					var classified uint64
+					for _, c := range st.Counts {
+						for _, sc := range c {
+							for _, n := range sc {
+								classified += n
+							}
+						}
+					}
+					if classified != st.Packets {
+						t.Errorf("%s, classified:%d != st.Packets:%d", t.Name(), classified, st.Packets)
+						return
+					}
+					_ = s.MissingRanges()
+					_ = s.Jitter()
+				}
+			}()
+		}
+
+		var writers sync.WaitGroup
+		for w := 0; w < tc.writers; w++ {
+			writers.Add(1)
+			go func() {
+				defer writers.Done()
+				for j := 0; j < tc.loops; j++ {
+					_, e := s.PacketArrival(uint16(j))
+					if e != nil {
+						t.Errorf("%s, e != nil:%v", t.Name(), e)
+						return
+					}
+				}
+			}()
+		}
+
+		writers.Wait()
+		close(done)
+		readers.Wait()
+
+		st := s.Stats()
+		if !reflect.DeepEqual(st.Packets, uint64(tc.writers*tc.loops)) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(st.Packets:%v, %v)", t.Name(), i, st.Packets, tc.writers*tc.loops)
+		}
+
+		// the atomic snapshot must match the Tracker once the writers are done
+		// f must not call the SafeTracker, so the Tracker's values are compared afterwards
+		var trStats Stats
+		var trLen int
+		var trMax uint16
+		s.Locked(func(tr *Tracker) {
+			trStats, trLen, trMax = tr.Stats(), tr.Len(), tr.Max()
+		})
+		if !reflect.DeepEqual(st, trStats) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(st:%v, tr.Stats():%v)", t.Name(), i, st, trStats)
+		}
+		if !reflect.DeepEqual(s.Len(), trLen) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Len():%v, tr.Len():%v)", t.Name(), i, s.Len(), trLen)
+		}
+		if !reflect.DeepEqual(s.Max(), trMax) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Max():%v, tr.Max():%v)", t.Name(), i, s.Max(), trMax)
+		}
+
+		s.ResetStats()
+		if !reflect.DeepEqual(s.Stats(), Stats{}) {
+			t.Fatalf("%s, test:%d ResetStats() did not clear the stats", t.Name(), i)
+		}
+	}
+}
+
+// TestSafeTrackerAllocs checks the arrivals don't allocate a snapshot, so
+// the only allocation is the Taxonomy
+func TestSafeTrackerAllocs(t *testing.T) {
+
+	tr, err := New(100, 100, 100, 100, 0)
+	if err != nil {
+		t.Fatalf("%s, err:%v", t.Name(), err)
+	}
+	s := NewSafe(tr)
+
+	var seq uint16
+	arrival := func() {
+		seq++
+		if _, err := s.PacketArrival(seq); err != nil {
+			t.Fatalf("%s, PacketArrival err:%v", t.Name(), err)
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		arrival()
+	}
+
+	if allocs := testing.AllocsPerRun(1000, arrival); allocs > 1 {
+		t.Fatalf("%s, allocs:%f > 1", t.Name(), allocs)
+	}
+
+	// the snapshot is rebuilt once, and then reused until the next arrival
+	if st := s.Stats(); st.Packets != 2001 {
+		t.Fatalf("%s, Packets:%d != 2001", t.Name(), st.Packets)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { _ = s.Stats() }); allocs != 0 {
+		t.Fatalf("%s, Stats() allocs:%f != 0", t.Name(), allocs)
+	}
+}
@@ -0,0 +1,124 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Cumulative statistics
+//
+// Every Taxonomy returned by PacketArrival is counted here, so callers
+// don't need to keep their own counters.
+
+// JumpBuckets are the upper bounds (inclusive) of the Taxonomy.Jump histogram
+// Jumps larger than the last bound are counted in the final overflow bucket
+var JumpBuckets = [JumpBucketCount - 1]uint16{1, 2, 3, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+const (
+	JumpBucketCount = 12
+)
+
+// Stats holds monotonic counters of the packet classifications
+// Counts is indexed by [Position][Category][SubCategory]
+type Stats struct {
+	Packets   uint64
+	Counts    [PositionCount][CategoryCount][SubCategoryCount]uint64
+	JumpTotal uint64 // sum of Taxonomy.Jump
+	Lost      uint64 // sum of Taxonomy.Lost, the RFC 3550 "cumulative number of packets lost"
+
+	// JumpHistogram counts the non-zero Taxonomy.Jump, see JumpBuckets
+	// The buckets are not cumulative
+	JumpHistogram [JumpBucketCount]uint64
+}
+
+// jumpBucket returns the Stats.JumpHistogram index for the jump
+func jumpBucket(jump uint16) int {
+	for i, b := range JumpBuckets {
+		if jump <= b {
+			return i
+		}
+	}
+	return JumpBucketCount - 1
+}
+
+// add counts a single Taxonomy
+func (s *Stats) add(tax *Taxonomy) {
+	s.Packets++
+	s.Counts[tax.Position][tax.Categroy][tax.SubCategory]++
+	s.JumpTotal += uint64(tax.Jump)
+	s.Lost += uint64(tax.Lost)
+	if tax.Jump > 0 {
+		s.JumpHistogram[jumpBucket(tax.Jump)]++
+	}
+}
+
+// Count returns the counter for a single Position/Category/SubCategory combination
+func (s *Stats) Count(position, category, subCategory int) uint64 {
+	if position < 0 || position >= PositionCount ||
+		category < 0 || category >= CategoryCount ||
+		subCategory < 0 || subCategory >= SubCategoryCount {
+		return 0
+	}
+	return s.Counts[position][category][subCategory]
+}
+
+// sum adds the counters matching the filter function
+func (s *Stats) sum(match func(p, c, sc int) bool) (total uint64) {
+	for p := 0; p < PositionCount; p++ {
+		for c := 0; c < CategoryCount; c++ {
+			for sc := 0; sc < SubCategoryCount; sc++ {
+				if match(p, c, sc) {
+					total += s.Counts[p][c][sc]
+				}
+			}
+		}
+	}
+	return total
+}
+
+// Next returns the count of packets which were the next expected sequence number (Max()+1)
+func (s *Stats) Next() uint64 {
+	return s.sum(func(p, c, sc int) bool { return sc == SubCategoryNext })
+}
+
+// Jumps returns the count of packets which jumped ahead by more than one
+func (s *Stats) Jumps() uint64 {
+	return s.sum(func(p, c, sc int) bool { return sc == SubCategoryJump })
+}
+
+// Late returns the count of packets which filled a hole more than the late threshold behind Max()
+func (s *Stats) Late() uint64 {
+	return s.sum(func(p, c, sc int) bool { return sc == SubCategoryLate })
+}
+
+// Reordered returns the count of packets which filled a hole within the late threshold behind Max()
+func (s *Stats) Reordered() uint64 {
+	return s.sum(func(p, c, sc int) bool { return sc == SubCategoryReordered })
+}
+
+// Duplicates returns the count of duplicate packets, including duplicates of Max()
+func (s *Stats) Duplicates() uint64 {
+	return s.sum(func(p, c, sc int) bool { return p == PositionDuplicate || sc == SubCategoryDuplicate })
+}
+
+// Buffer returns the count of packets which landed in the ahead or behind safety buffers
+func (s *Stats) Buffer() uint64 {
+	return s.sum(func(p, c, sc int) bool { return c == CategoryBuffer })
+}
+
+// Restarts returns the count of packets which caused the window to restart
+func (s *Stats) Restarts() uint64 {
+	return s.sum(func(p, c, sc int) bool { return c == CategoryRestart })
+}
+
+// BehindWindow returns the count of packets which arrived behind Max(), but within the behind window
+func (s *Stats) BehindWindow() uint64 {
+	return s.sum(func(p, c, sc int) bool { return p == PositionBehind && c == CategoryWindow })
+}
+
+// Stats() returns a copy of the cumulative statistics
+func (t *Tracker) Stats() Stats {
+	return t.stats
+}
+
+// ResetStats() clears the cumulative statistics
+func (t *Tracker) ResetStats() {
+	t.stats = Stats{}
+}
@@ -0,0 +1,120 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+
+	type test struct {
+		aw           uint16
+		bw           uint16
+		ab           uint16
+		bb           uint16
+		seqs         []uint16
+		Packets      uint64
+		Next         uint64
+		Jumps        uint64
+		Duplicates   uint64
+		Buffer       uint64
+		Restarts     uint64
+		BehindWindow uint64
+		JumpTotal    uint64
+		JumpCount    uint64 // sum of the JumpHistogram buckets
+	}
+
+	tests := []test{
+		{10, 10, 10, 10, []uint16{0}, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+		{10, 10, 10, 10, []uint16{0, 1, 2, 3}, 4, 3, 0, 0, 0, 0, 0, 3, 3},
+		{10, 10, 10, 10, []uint16{0, 1, 1, 3, 2, 2, 15, 100}, 8, 1, 1, 2, 1, 1, 2, 5, 4},
+		{10, 10, 10, 10, []uint16{maxUint16, 0, 5, maxUint16 - 30}, 4, 1, 1, 0, 0, 1, 0, 6, 2},
+	}
+
+	for _, storage := range testStorages {
+		for i, tc := range tests {
+
+			t.Logf("%s storage:%d, i:%d, tc: %v\n", t.Name(), storage, i, tc)
+
+			tr, err := NewWithStorage(tc.aw, tc.bw, tc.ab, tc.bb, storage, debugLevelCst)
+			if err != nil {
+				t.Fatalf("%s, err:%v", t.Name(), err)
+			}
+
+			for _, seq := range tc.seqs {
+				_, e := tr.PacketArrival(seq)
+				if e != nil {
+					t.Fatalf("%s, err != nil:%v", t.Name(), e)
+				}
+			}
+
+			s := tr.Stats()
+
+			if !reflect.DeepEqual(s.Packets, tc.Packets) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Packets:%v, tc.Packets:%v)", t.Name(), i, s.Packets, tc.Packets)
+			}
+			if !reflect.DeepEqual(s.Next(), tc.Next) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Next():%v, tc.Next:%v)", t.Name(), i, s.Next(), tc.Next)
+			}
+			if !reflect.DeepEqual(s.Jumps(), tc.Jumps) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Jumps():%v, tc.Jumps:%v)", t.Name(), i, s.Jumps(), tc.Jumps)
+			}
+			if !reflect.DeepEqual(s.Duplicates(), tc.Duplicates) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Duplicates():%v, tc.Duplicates:%v)", t.Name(), i, s.Duplicates(), tc.Duplicates)
+			}
+			if !reflect.DeepEqual(s.Buffer(), tc.Buffer) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Buffer():%v, tc.Buffer:%v)", t.Name(), i, s.Buffer(), tc.Buffer)
+			}
+			if !reflect.DeepEqual(s.Restarts(), tc.Restarts) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Restarts():%v, tc.Restarts:%v)", t.Name(), i, s.Restarts(), tc.Restarts)
+			}
+			if !reflect.DeepEqual(s.BehindWindow(), tc.BehindWindow) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.BehindWindow():%v, tc.BehindWindow:%v)", t.Name(), i, s.BehindWindow(), tc.BehindWindow)
+			}
+			if !reflect.DeepEqual(s.JumpTotal, tc.JumpTotal) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.JumpTotal:%v, tc.JumpTotal:%v)", t.Name(), i, s.JumpTotal, tc.JumpTotal)
+			}
+
+			var jumpCount uint64
+			for _, c := range s.JumpHistogram {
+				jumpCount += c
+			}
+			if !reflect.DeepEqual(jumpCount, tc.JumpCount) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(jumpCount:%v, tc.JumpCount:%v)", t.Name(), i, jumpCount, tc.JumpCount)
+			}
+
+			tr.ResetStats()
+			if !reflect.DeepEqual(tr.Stats(), Stats{}) {
+				t.Fatalf("%s, test:%d ResetStats() did not clear the stats", t.Name(), i)
+			}
+		}
+	}
+}
+
+func TestJumpBucket(t *testing.T) {
+
+	type test struct {
+		jump   uint16
+		bucket int
+	}
+
+	tests := []test{
+		{1, 0},
+		{2, 1},
+		{4, 3},
+		{5, 3},
+		{1000, JumpBucketCount - 2},
+		{1001, JumpBucketCount - 1},
+		{maxUint16, JumpBucketCount - 1},
+	}
+
+	for i, tc := range tests {
+		if b := jumpBucket(tc.jump); b != tc.bucket {
+			t.Fatalf("%s, test:%d jumpBucket(%d):%d != tc.bucket:%d", t.Name(), i, tc.jump, b, tc.bucket)
+		}
+	}
+}
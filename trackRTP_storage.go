@@ -0,0 +1,128 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Storage of the received sequence numbers
+//
+// The Tracker stores the received sequence numbers within the window.
+// The original, and default, storage is the B-tree.  For windows bounded
+// by MaxWindowCst, the bitmap ring gives O(1) insert and zero allocations.
+//
+// Both are ordered using the wrap aware isLess.
+
+import (
+	"log"
+
+	"github.com/google/btree"
+)
+
+// Storage
+const (
+	StorageBTree int = iota
+	StorageBitmap
+)
+
+// Storage holds the sequence numbers received within the window
+// All the items must be within 2^15 of each other, so they can be
+// ordered using isLess
+type Storage interface {
+	Insert(seq uint16) (already bool)
+	Has(seq uint16) bool
+	Max() (uint16, bool)
+	Min() (uint16, bool)
+	Len() int
+	// EvictBefore deletes all the items isLess than back, returning the number deleted
+	EvictBefore(back uint16) (deleted int)
+	Clear()
+	Ascend(f func(seq uint16) bool)
+	Descend(f func(seq uint16) bool)
+}
+
+// newStorage creates the storage selected
+func newStorage(storage int, degree int) Storage {
+	switch storage {
+	case StorageBitmap:
+		return newBitmapStorage()
+	}
+	return newBTreeStorage(degree)
+}
+
+// btreeStorage is the B-tree storage
+// See also: https://pkg.go.dev/github.com/google/btree
+type btreeStorage struct {
+	b *btree.BTreeG[uint16]
+
+	deleted []uint16 // reused by EvictBefore
+}
+
+func newBTreeStorage(degree int) *btreeStorage {
+	return &btreeStorage{
+		b: btree.NewG[uint16](degree, isLess),
+		//b:        btree.NewOrderedG[uint16](degree),
+	}
+}
+
+// Insert adds the seq
+// https://pkg.go.dev/github.com/google/btree#BTree.ReplaceOrInsert
+func (s *btreeStorage) Insert(seq uint16) (already bool) {
+	_, already = s.b.ReplaceOrInsert(seq)
+	return already
+}
+
+func (s *btreeStorage) Has(seq uint16) bool {
+	return s.b.Has(seq)
+}
+
+func (s *btreeStorage) Max() (uint16, bool) {
+	return s.b.Max()
+}
+
+func (s *btreeStorage) Min() (uint16, bool) {
+	return s.b.Min()
+}
+
+func (s *btreeStorage) Len() int {
+	return s.b.Len()
+}
+
+// EvictBefore iterates to find the items which are falling off the back
+// ( An alternative strategy would be to loop doing deleteMin,
+// but that would be more calls to the btree. )
+// The btree must not be modified during iteration, otherwise
+// items are skipped, so the deletes happen after the Ascend.
+func (s *btreeStorage) EvictBefore(back uint16) (deleted int) {
+
+	s.deleted = s.deleted[:0]
+
+	//t.b.DescendLessOrEqual(backOfWindow, func(item uint16) bool {
+	s.b.Ascend(func(item uint16) bool {
+		if isLess(item, back) {
+			s.deleted = append(s.deleted, item)
+			return true
+		}
+		return false
+	})
+
+	for _, item := range s.deleted {
+		_, ok := s.b.Delete(item)
+		if !ok {
+			log.Panicf("EvictBefore Delete not ok:%v", item)
+		}
+	}
+
+	return len(s.deleted)
+}
+
+// Clear removes all the items, putting them on the freelist
+// See also: https://pkg.go.dev/github.com/google/btree#BTreeG.Clear
+func (s *btreeStorage) Clear() {
+	s.b.Clear(ClearFreeListCst)
+}
+
+func (s *btreeStorage) Ascend(f func(seq uint16) bool) {
+	s.b.Ascend(f)
+}
+
+func (s *btreeStorage) Descend(f func(seq uint16) bool) {
+	s.b.Descend(f)
+}
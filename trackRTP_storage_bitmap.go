@@ -0,0 +1,157 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// bitmapStorage is a fixed size bitset ring, indexed by seq % bitmapSizeCst
+//
+// The window is at most aw + bw <= 2 * MaxWindowCst packets, and an ahead
+// packet of up to aw is inserted before the back of the window is evicted,
+// so all the items fit in a ring of 3 * MaxWindowCst without aliasing.
+// Insert is O(1), and eviction is proportional to the number of sequence
+// numbers falling off the back, which is one (1) in the common case.
+// There are no allocations.
+
+const (
+	bitmapSizeCst = 8192 // power of two, larger than 3 * MaxWindowCst
+	bitmapMaskCst = bitmapSizeCst - 1
+	bitmapWordCst = 64
+)
+
+type bitmapStorage struct {
+	bits [bitmapSizeCst / bitmapWordCst]uint64
+
+	min uint16
+	max uint16
+	len int
+}
+
+func newBitmapStorage() *bitmapStorage {
+	return &bitmapStorage{}
+}
+
+// bit returns the word index and mask for the seq
+func (s *bitmapStorage) bit(seq uint16) (int, uint64) {
+	i := int(seq) & bitmapMaskCst
+	return i / bitmapWordCst, 1 << (uint(i) % bitmapWordCst)
+}
+
+func (s *bitmapStorage) isSet(seq uint16) bool {
+	w, m := s.bit(seq)
+	return s.bits[w]&m != 0
+}
+
+// Insert adds the seq, which must be within bitmapSizeCst of the other items
+func (s *bitmapStorage) Insert(seq uint16) (already bool) {
+
+	if s.Has(seq) {
+		return true
+	}
+
+	w, m := s.bit(seq)
+	s.bits[w] |= m
+
+	if s.len == 0 {
+		s.min = seq
+		s.max = seq
+	} else {
+		if isLess(s.max, seq) {
+			s.max = seq
+		}
+		if isLess(seq, s.min) {
+			s.min = seq
+		}
+	}
+	s.len++
+
+	return false
+}
+
+// Has checks the seq is between Min() and Max(), so an alias in the ring
+// isn't mistaken for the seq
+func (s *bitmapStorage) Has(seq uint16) bool {
+	if s.len == 0 || isLess(seq, s.min) || isLess(s.max, seq) {
+		return false
+	}
+	return s.isSet(seq)
+}
+
+func (s *bitmapStorage) Max() (uint16, bool) {
+	return s.max, s.len > 0
+}
+
+func (s *bitmapStorage) Min() (uint16, bool) {
+	return s.min, s.len > 0
+}
+
+func (s *bitmapStorage) Len() int {
+	return s.len
+}
+
+// EvictBefore clears the bits from Min() up to back, and then finds the new Min()
+func (s *bitmapStorage) EvictBefore(back uint16) (deleted int) {
+
+	if s.len == 0 || !isLess(s.min, back) {
+		return 0
+	}
+
+	if isLess(s.max, back) {
+		deleted = s.len
+		s.Clear()
+		return deleted
+	}
+
+	for seq := s.min; seq != back; seq++ {
+		if s.isSet(seq) {
+			w, m := s.bit(seq)
+			s.bits[w] &^= m
+			deleted++
+		}
+	}
+	s.len -= deleted
+
+	// max is still set, so this terminates
+	for seq := back; ; seq++ {
+		if s.isSet(seq) {
+			s.min = seq
+			break
+		}
+	}
+
+	return deleted
+}
+
+// Clear removes all the items
+func (s *bitmapStorage) Clear() {
+	s.bits = [bitmapSizeCst / bitmapWordCst]uint64{}
+	s.len = 0
+	s.min = 0
+	s.max = 0
+}
+
+func (s *bitmapStorage) Ascend(f func(seq uint16) bool) {
+	if s.len == 0 {
+		return
+	}
+	for seq := s.min; ; seq++ {
+		if s.isSet(seq) && !f(seq) {
+			return
+		}
+		if seq == s.max {
+			return
+		}
+	}
+}
+
+func (s *bitmapStorage) Descend(f func(seq uint16) bool) {
+	if s.len == 0 {
+		return
+	}
+	for seq := s.max; ; seq-- {
+		if s.isSet(seq) && !f(seq) {
+			return
+		}
+		if seq == s.min {
+			return
+		}
+	}
+}
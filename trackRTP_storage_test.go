@@ -0,0 +1,151 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestStorageEquivalent feeds the same random arrivals to the btree and
+// bitmap Trackers, which must classify every packet identically
+func TestStorageEquivalent(t *testing.T) {
+
+	type test struct {
+		aw          uint16
+		bw          uint16
+		ab          uint16
+		bb          uint16
+		start       uint16
+		loops       int
+		MaxRandJump uint32
+	}
+
+	tests := []test{
+		{10, 10, 10, 10, 0, 10000, 5},
+		{10, 10, 10, 10, maxUint16 - 100, 10000, 30},
+		{100, 100, 100, 100, 0, 100000, 50},
+		{1500, 1500, 1500, 1500, maxUint16 - 1000, 100000, 500},
+		{1500, 1500, 1500, 1500, 0, 100000, 3000},
+	}
+
+	for i, tc := range tests {
+
+		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
+
+		bt, err := NewWithStorage(tc.aw, tc.bw, tc.ab, tc.bb, StorageBTree, 0)
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+		bm, err := NewWithStorage(tc.aw, tc.bw, tc.ab, tc.bb, StorageBitmap, 0)
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
+		}
+
+		s := tc.start
+		for j := 0; j < tc.loops; j++ {
+
+			seq := s
+			r := uint16(FastRandN(tc.MaxRandJump))
+			if FastRandN(2) == 1 {
+				seq -= r
+			} else {
+				seq += r
+			}
+
+			taxBT, e := bt.PacketArrival(seq)
+			if e != nil {
+				t.Fatalf("%s, e != nil:%v", t.Name(), e)
+			}
+			taxBM, e := bm.PacketArrival(seq)
+			if e != nil {
+				t.Fatalf("%s, e != nil:%v", t.Name(), e)
+			}
+
+			if !reflect.DeepEqual(taxBT, taxBM) {
+				t.Fatalf("%s, test:%d j:%d seq:%d !reflect.DeepEqual(taxBT:%v, taxBM:%v)", t.Name(), i, j, seq, taxBT, taxBM)
+			}
+			if bt.Max() != bm.Max() || bt.Min() != bm.Min() || bt.Len() != bm.Len() {
+				t.Fatalf("%s, test:%d j:%d seq:%d btree max:%d min:%d len:%d != bitmap max:%d min:%d len:%d",
+					t.Name(), i, j, seq, bt.Max(), bt.Min(), bt.Len(), bm.Max(), bm.Min(), bm.Len())
+			}
+
+			s++
+		}
+
+		if !reflect.DeepEqual(bt.itemsDescending(), bm.itemsDescending()) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(bt.itemsDescending(), bm.itemsDescending())", t.Name(), i)
+		}
+		if !reflect.DeepEqual(bt.Stats(), bm.Stats()) {
+			t.Fatalf("%s, test:%d !reflect.DeepEqual(bt.Stats():%v, bm.Stats():%v)", t.Name(), i, bt.Stats(), bm.Stats())
+		}
+	}
+}
+
+func TestStorageInit(t *testing.T) {
+
+	_, err := NewWithStorage(10, 10, 10, 10, StorageBitmap+1, 0)
+	if !errors.Is(err, ErrStorage) {
+		t.Fatalf("%s, err:%v != ErrStorage", t.Name(), err)
+	}
+}
+
+// TestStorageAllocs checks the steady state allocations per packet, which
+// are only the Taxonomy, including evicting the losses
+func TestStorageAllocs(t *testing.T) {
+
+	for _, storage := range testStorages {
+
+		tr, err := NewWithStorage(100, 100, 100, 100, storage, 0)
+		if err != nil {
+			t.Fatalf("%s, storage:%d err:%v", t.Name(), storage, err)
+		}
+		tr.SetCallbacks(Callbacks{OnEvictedLoss: func(seqs []uint16) {}})
+
+		// every 10th packet is lost
+		var seq uint16
+		arrival := func() {
+			seq++
+			if seq%10 == 0 {
+				seq++
+			}
+			if _, err := tr.PacketArrival(seq); err != nil {
+				t.Fatalf("%s, storage:%d PacketArrival err:%v", t.Name(), storage, err)
+			}
+		}
+
+		// fill the window, so the losses are falling off the back
+		for i := 0; i < 1000; i++ {
+			arrival()
+		}
+
+		if allocs := testing.AllocsPerRun(1000, arrival); allocs > 1 {
+			t.Fatalf("%s, storage:%d allocs:%f > 1", t.Name(), storage, allocs)
+		}
+	}
+}
+
+func benchmarkStorage(b *testing.B, storage int) {
+	tr, err := NewWithStorage(100, 100, 100, 100, storage, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tr.PacketArrival(uint16(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStorageBTree(b *testing.B) {
+	benchmarkStorage(b, StorageBTree)
+}
+
+func BenchmarkStorageBitmap(b *testing.B) {
+	benchmarkStorage(b, StorageBitmap)
+}
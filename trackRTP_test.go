@@ -5,11 +5,14 @@ package goTrackRTP
 // See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
 
 import (
+	"errors"
 	"math"
 	"os"
 	"reflect"
 	"testing"
 	_ "unsafe"
+
+	"github.com/randomizedcoder/goTrackRTP/rtp"
 )
 
 // unsafe for fastrand
@@ -19,6 +22,10 @@ const (
 	WindowSizeTestingCst = 100
 )
 
+// testStorages are the storages the table tests are run against,
+// which must give identical classification results
+var testStorages = []int{StorageBTree, StorageBitmap}
+
 // unsafe for the FastRand()
 //_ "unsafe"
 // //go:linkname FastRand runtime.fastrand
@@ -73,7 +80,7 @@ func TestTrackerInit(t *testing.T) {
 
 		tr, err := New(tc.aw, tc.bw, tc.ab, tc.bb, debugLevelCst)
 
-		if err != tc.err {
+		if !errors.Is(err, tc.err) {
 			t.Fatalf("%s, err:%v != tc.err:%v", t.Name(), err, tc.err)
 		}
 
@@ -138,10 +145,12 @@ func TestTrackerWindow(t *testing.T) {
 
 		// - note because we only insert x2 in this test, we can't test PositionAhead + SubCategoryDuplicate
 		// position behind - window
-		{10, 10, 10, 10, 0, maxUint16, nil, 10 + 10, 2, 0, 1, PositionBehind, CategoryWindow, SubCategoryUnknown},
-		{10, 10, 10, 10, 0, maxUint16 - 5, nil, 10 + 10, 2, 0, 6, PositionBehind, CategoryWindow, SubCategoryUnknown},
-		{10, 10, 10, 10, 0, maxUint16 - 9, nil, 10 + 10, 2, 0, 10, PositionBehind, CategoryWindow, SubCategoryUnknown},
-		{100, 100, 100, 100, 0, maxUint16, nil, 100 + 100, 2, 0, 1, PositionBehind, CategoryWindow, SubCategoryUnknown},
+		{10, 10, 10, 10, 0, maxUint16, nil, 10 + 10, 2, 0, 1, PositionBehind, CategoryWindow, SubCategoryReordered},
+		{10, 10, 10, 10, 0, maxUint16 - 2, nil, 10 + 10, 2, 0, 3, PositionBehind, CategoryWindow, SubCategoryReordered},
+		{10, 10, 10, 10, 0, maxUint16 - 3, nil, 10 + 10, 2, 0, 4, PositionBehind, CategoryWindow, SubCategoryLate},
+		{10, 10, 10, 10, 0, maxUint16 - 5, nil, 10 + 10, 2, 0, 6, PositionBehind, CategoryWindow, SubCategoryLate},
+		{10, 10, 10, 10, 0, maxUint16 - 9, nil, 10 + 10, 2, 0, 10, PositionBehind, CategoryWindow, SubCategoryLate},
+		{100, 100, 100, 100, 0, maxUint16, nil, 100 + 100, 2, 0, 1, PositionBehind, CategoryWindow, SubCategoryReordered},
 		// position behind - buffer
 		{10, 10, 10, 10, 0, maxUint16 - 10, nil, 10 + 10, 1, 0, 0, PositionBehind, CategoryBuffer, SubCategoryUnknown},
 		{10, 10, 10, 10, 0, maxUint16 - 11, nil, 10 + 10, 1, 0, 0, PositionBehind, CategoryBuffer, SubCategoryUnknown},
@@ -155,39 +164,219 @@ func TestTrackerWindow(t *testing.T) {
 		{100, 100, 100, 100, 0, maxUint16 - 200, nil, 100 + 100, 1, maxUint16 - 200, 0, PositionBehind, CategoryRestart, SubCategoryUnknown},
 	}
 
-	for i, tc := range tests {
+	for _, storage := range testStorages {
+		for i, tc := range tests {
 
-		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
+			t.Logf("%s storage:%d, i:%d, tc: %v\n", t.Name(), storage, i, tc)
 
-		tr, err := New(tc.aw, tc.bw, tc.ab, tc.bb, debugLevelCst)
-		if err != tc.err {
-			t.Fatalf("%s, err:%v != tc.err:%v", t.Name(), err, tc.err)
-		}
+			tr, err := NewWithStorage(tc.aw, tc.bw, tc.ab, tc.bb, storage, debugLevelCst)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("%s, err:%v != tc.err:%v", t.Name(), err, tc.err)
+			}
 
-		_, e := tr.PacketArrival(tc.m)
-		if e != nil {
-			t.Fatalf("%s, err != nil:%v", t.Name(), e)
+			_, e := tr.PacketArrival(tc.m)
+			if e != nil {
+				t.Fatalf("%s, err != nil:%v", t.Name(), e)
+			}
+
+			tax, et := tr.PacketArrival(tc.seq)
+			if et != nil {
+				t.Fatalf("%s, err != nil:%v", t.Name(), et)
+			}
+
+			if !reflect.DeepEqual(tr.Window, tc.Window) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tr.Window:%v, tc.Window:%v)", t.Name(), i, tr.Window, tc.Window)
+			}
+
+			if !reflect.DeepEqual(tax.Len, tc.Len) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.Len:%v, tc.Len:%v)", t.Name(), i, tax.Len, tc.Len)
+			}
+
+			if !reflect.DeepEqual(tr.Max(), tc.Max) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tr.Max():%v, tc.Max:%v)", t.Name(), i, tr.Max(), tc.Max)
+			}
+
+			if !reflect.DeepEqual(tax.Jump, tc.Jump) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.Jump:%v, tc.Jump:%v)", t.Name(), i, tax.Jump, tc.Jump)
+			}
+
+			if !reflect.DeepEqual(tax.Position, tc.Position) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.Position:%v, tc.Position:%v)", t.Name(), i, tax.Position, tc.Position)
+			}
+			if !reflect.DeepEqual(tax.Categroy, tc.Category) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.Categroy:%v, tc.Category:%v)", t.Name(), i, tax.Categroy, tc.Category)
+			}
+			if !reflect.DeepEqual(tax.SubCategory, tc.SubCategory) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.SubCategory:%v, tc.SubCategory:%v)", t.Name(), i, tax.SubCategory, tc.SubCategory)
+			}
 		}
+	}
+}
 
-		tax, et := tr.PacketArrival(tc.seq)
-		if et != nil {
-			t.Fatalf("%s, err != nil:%v", t.Name(), et)
+func TestLateThreshold(t *testing.T) {
+
+	type test struct {
+		aw          uint16
+		bw          uint16
+		ab          uint16
+		bb          uint16
+		lt          uint16
+		err         error
+		m           uint16
+		seq         uint16
+		SubCategory int
+	}
+
+	tests := []test{
+		{10, 10, 10, 10, 0, nil, 10, 9, SubCategoryLate},
+		{10, 10, 10, 10, 1, nil, 10, 9, SubCategoryReordered},
+		{10, 10, 10, 10, 1, nil, 10, 8, SubCategoryLate},
+		{10, 10, 10, 10, 5, nil, 10, 5, SubCategoryReordered},
+		{10, 10, 10, 10, 5, nil, 10, 4, SubCategoryLate},
+		{10, 10, 10, 10, 10, nil, 10, 0, SubCategoryReordered},
+		{10, 10, 10, 10, 5, nil, 2, maxUint16 - 2, SubCategoryReordered},
+		{10, 10, 10, 10, 5, nil, 2, maxUint16 - 3, SubCategoryLate},
+		// errors
+		{10, 10, 10, 10, 11, ErrLateThresholdMax, 10, 0, SubCategoryUnknown},
+	}
+
+	for _, storage := range testStorages {
+		for i, tc := range tests {
+
+			t.Logf("%s storage:%d, i:%d, tc: %v\n", t.Name(), storage, i, tc)
+
+			tr, err := NewWithStorage(tc.aw, tc.bw, tc.ab, tc.bb, storage, debugLevelCst)
+			if err != nil {
+				t.Fatalf("%s, err:%v", t.Name(), err)
+			}
+
+			err = tr.SetLateThreshold(tc.lt)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("%s, err:%v != tc.err:%v", t.Name(), err, tc.err)
+			}
+			if err != nil {
+				continue
+			}
+
+			_, e := tr.PacketArrival(tc.m)
+			if e != nil {
+				t.Fatalf("%s, err != nil:%v", t.Name(), e)
+			}
+
+			tax, et := tr.PacketArrival(tc.seq)
+			if et != nil {
+				t.Fatalf("%s, err != nil:%v", t.Name(), et)
+			}
+
+			if !reflect.DeepEqual(tax.SubCategory, tc.SubCategory) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.SubCategory:%v, tc.SubCategory:%v)", t.Name(), i, tax.SubCategory, tc.SubCategory)
+			}
 		}
+	}
+}
+
+func TestLost(t *testing.T) {
+
+	type test struct {
+		aw       uint16
+		bw       uint16
+		ab       uint16
+		bb       uint16
+		seqs     []uint16
+		LastLost uint16
+		Lost     uint64
+	}
+
+	tests := []test{
+		// no loss
+		{5, 5, 10, 10, []uint16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, 0, 0},
+		// holes still in the window are not lost yet
+		{5, 5, 10, 10, []uint16{0, 2, 4, 6}, 0, 0},
+		// seq:1 falls off the back
+		{5, 5, 10, 10, []uint16{0, 2, 4, 6, 8, 10, 12}, 1, 1},
+		// 1 and 3 fall off the back together
+		{5, 5, 10, 10, []uint16{0, 2, 4, 6, 8, 10, 14}, 2, 2},
+		// late packet fills the hole before it falls off the back
+		{5, 5, 10, 10, []uint16{0, 2, 4, 1, 6, 8, 10, 12}, 0, 0},
+		// behind packet older than the first packet extends the tracked range
+		{10, 10, 10, 10, []uint16{10, 5, 20, 30}, 4, 4},
+		// wrap
+		{5, 5, 10, 10, []uint16{maxUint16 - 1, 0, 2, 4, 6, 8, 10}, 1, 1},
+		// restart counts the holes in the window that was cleared
+		{10, 10, 10, 10, []uint16{0, 2, 5, 100}, 3, 3},
+	}
+
+	for _, storage := range testStorages {
+		for i, tc := range tests {
+
+			t.Logf("%s storage:%d, i:%d, tc: %v\n", t.Name(), storage, i, tc)
+
+			tr, err := NewWithStorage(tc.aw, tc.bw, tc.ab, tc.bb, storage, debugLevelCst)
+			if err != nil {
+				t.Fatalf("%s, err:%v", t.Name(), err)
+			}
+
+			var tax *Taxonomy
+			for _, seq := range tc.seqs {
+				var e error
+				tax, e = tr.PacketArrival(seq)
+				if e != nil {
+					t.Fatalf("%s, err != nil:%v", t.Name(), e)
+				}
+			}
+
+			if !reflect.DeepEqual(tax.Lost, tc.LastLost) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.Lost:%v, tc.LastLost:%v)", t.Name(), i, tax.Lost, tc.LastLost)
+			}
 
-		if !reflect.DeepEqual(tr.Window, tc.Window) {
-			t.Fatalf("%s, test:%d !reflect.DeepEqual(tr.Window:%v, tc.Window:%v)", t.Name(), i, tr.Window, tc.Window)
+			s := tr.Stats()
+			if !reflect.DeepEqual(s.Lost, tc.Lost) {
+				t.Fatalf("%s, test:%d !reflect.DeepEqual(s.Lost:%v, tc.Lost:%v)", t.Name(), i, s.Lost, tc.Lost)
+			}
 		}
+	}
+}
 
-		if !reflect.DeepEqual(tax.Len, tc.Len) {
-			t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.Len:%v, tc.Len:%v)", t.Name(), i, tax.Len, tc.Len)
+func TestProcessPacket(t *testing.T) {
+
+	type test struct {
+		buf         []byte
+		err         error
+		Position    int
+		Category    int
+		SubCategory int
+	}
+
+	// first packet is seq:0x1234
+	tests := []test{
+		{[]byte{0x80, 0x21, 0x12, 0x35, 0x00, 0x00, 0x00, 0x01, 0xDE, 0xAD, 0xBE, 0xEF}, nil, PositionAhead, CategoryWindow, SubCategoryNext},
+		{[]byte{0x80, 0x21, 0x12, 0x34, 0x00, 0x00, 0x00, 0x01, 0xDE, 0xAD, 0xBE, 0xEF}, nil, PositionDuplicate, CategoryUnknown, SubCategoryUnknown},
+		{[]byte{0x80, 0x21, 0x12, 0x33, 0x00, 0x00, 0x00, 0x01, 0xDE, 0xAD, 0xBE, 0xEF}, nil, PositionBehind, CategoryWindow, SubCategoryReordered},
+		// errors
+		{[]byte{0x80, 0x21, 0x12, 0x35}, rtp.ErrHeaderShort, PositionUnknown, CategoryUnknown, SubCategoryUnknown},
+		{[]byte{0x00, 0x21, 0x12, 0x35, 0x00, 0x00, 0x00, 0x01, 0xDE, 0xAD, 0xBE, 0xEF}, rtp.ErrVersion, PositionUnknown, CategoryUnknown, SubCategoryUnknown},
+	}
+
+	for i, tc := range tests {
+
+		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
+
+		tr, err := New(10, 10, 10, 10, debugLevelCst)
+		if err != nil {
+			t.Fatalf("%s, err:%v", t.Name(), err)
 		}
 
-		if !reflect.DeepEqual(tr.Max(), tc.Max) {
-			t.Fatalf("%s, test:%d !reflect.DeepEqual(tr.Max():%v, tc.Max:%v)", t.Name(), i, tr.Max(), tc.Max)
+		_, e := tr.PacketArrival(0x1234)
+		if e != nil {
+			t.Fatalf("%s, err != nil:%v", t.Name(), e)
 		}
 
-		if !reflect.DeepEqual(tax.Jump, tc.Jump) {
-			t.Fatalf("%s, test:%d !reflect.DeepEqual(tax.Jump:%v, tc.Jump:%v)", t.Name(), i, tax.Jump, tc.Jump)
+		tax, et := tr.ProcessPacket(tc.buf)
+		if !errors.Is(et, tc.err) {
+			t.Fatalf("%s, test:%d err:%v != tc.err:%v", t.Name(), i, et, tc.err)
+		}
+		if et != nil {
+			continue
 		}
 
 		if !reflect.DeepEqual(tax.Position, tc.Position) {
@@ -241,7 +430,7 @@ func TestLongRunningWindow(t *testing.T) {
 		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
 
 		tr, err := New(tc.aw, tc.bw, tc.ab, tc.bb, tc.dl)
-		if err != tc.err {
+		if !errors.Is(err, tc.err) {
 			t.Fatalf("%s, err:%v != tc.err:%v", t.Name(), err, tc.err)
 		}
 
@@ -315,7 +504,7 @@ func TestLongRunningBackwardDuplicates(t *testing.T) {
 		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
 
 		tr, err := New(tc.aw, tc.bw, tc.ab, tc.bb, tc.dl)
-		if err != tc.err {
+		if !errors.Is(err, tc.err) {
 			t.Fatalf("%s, err:%v != tc.err:%v", t.Name(), err, tc.err)
 		}
 
@@ -421,7 +610,7 @@ func TestLongRunningSkipSend(t *testing.T) {
 		t.Logf("%s i:%d, tc: %v\n", t.Name(), i, tc)
 
 		tr, err := New(tc.aw, tc.bw, tc.ab, tc.bb, tc.dl)
-		if err != tc.err {
+		if !errors.Is(err, tc.err) {
 			t.Fatalf("%s, err:%v != tc.err:%v", t.Name(), err, tc.err)
 		}
 
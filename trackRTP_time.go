@@ -0,0 +1,249 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// Time based windows
+//
+// Streams vary from ~50 packets per second audio, to ~90k packets per
+// second video, so windows in packets are too small for one, or too
+// large for the other.  With TimeWindows the windows are durations.
+//
+// The classification is still done in sequence numbers, so the durations
+// are converted to packets using the packet rate, which is measured over
+// the behind window.  The packet counts are limited to the same
+// MinWindowCst and MaxWindowCst as the packet windows.
+//
+// Each time Max() moves forward, the sequence number and arrival time are
+// recorded.  Once the arrival time of a Max() is older than the behind
+// window, it, and everything behind it, is evicted, and the gaps are
+// counted as lost, the same as for packet windows.  Max() itself is never
+// evicted, so if the stream stops, the window shrinks to just Max().
+//
+// The eviction is done before the packet is classified, so a packet
+// arriving after a pause is classified against the window as of its
+// arrival, and again afterwards, as a new Max() may evict the previous one.
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// TimeWindowRateCst is the packet rate used until it has been measured
+	TimeWindowRateCst = 50
+
+	// rateMinSpanCst is the minimum time between the oldest and newest
+	// marks to measure the packet rate
+	rateMinSpanCst = 10 * time.Millisecond
+
+	// marksCompactCst is the minimum number of popped marks before compacting
+	marksCompactCst = 64
+)
+
+// TimeWindows are the windows and buffers as durations
+type TimeWindows struct {
+	AW   time.Duration // ahead window
+	BW   time.Duration // behind window
+	AB   time.Duration // ahead buffer
+	BB   time.Duration // behind buffer
+	Rate float64       // initial packets per second, zero (0) defaults to TimeWindowRateCst
+}
+
+// isSet is true if any of the fields are set, so a partial configuration
+// is validated, rather than silently falling back to the packet windows
+func (tw TimeWindows) isSet() bool {
+	return tw != TimeWindows{}
+}
+
+// mark is the arrival time of a Max()
+type mark struct {
+	seq uint16
+	at  time.Time
+}
+
+// timeWindows is the time window state
+type timeWindows struct {
+	config TimeWindows
+	rate   float64 // measured packets per second
+
+	marks []mark // Max() arrivals, oldest first, from head
+	head  int
+
+	// evicted is true once t.back has been moved by the arrival times.
+	// A behind packet older than t.back is then too late, rather than
+	// extending the window backwards.
+	evicted bool
+}
+
+// NewTime creates a Tracker using time based windows
+func NewTime(tw TimeWindows, debugLevel int) (*Tracker, error) {
+
+	return newTimeTracker(tw, StorageBTree, BtreeDegreeCst, debugLevel)
+}
+
+// newTimeTracker validates, and then creates the time window Tracker
+func newTimeTracker(tw TimeWindows, storage int, degree int, debugLevel int) (*Tracker, error) {
+
+	err := validateTimeWindows(tw)
+	if err != nil {
+		return nil, err
+	}
+
+	if tw.Rate == 0 {
+		tw.Rate = TimeWindowRateCst
+	}
+
+	// The minimums are replaced using the rate straight away
+	t, err := newTracker(MinWindowCst+1, MinWindowCst+1, MinWindowCst+1, MinWindowCst+1, storage, degree, debugLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	t.tw = &timeWindows{
+		config: tw,
+		rate:   tw.Rate,
+	}
+	t.setWindows()
+
+	return t, nil
+}
+
+// windowPackets converts a duration to packets at the measured rate
+func (tw *timeWindows) windowPackets(d time.Duration) uint16 {
+
+	p := math.Ceil(tw.rate * d.Seconds())
+	if p <= MinWindowCst {
+		return MinWindowCst + 1
+	}
+	if p > MaxWindowCst {
+		return MaxWindowCst
+	}
+
+	return uint16(p)
+}
+
+// setWindows sets the packet windows from the durations
+func (t *Tracker) setWindows() {
+
+	t.aw = t.tw.windowPackets(t.tw.config.AW)
+	t.bw = t.tw.windowPackets(t.tw.config.BW)
+	t.ab = t.tw.windowPackets(t.tw.config.AB)
+	t.bb = t.tw.windowPackets(t.tw.config.BB)
+
+	t.awPlusAb = t.aw + t.ab
+	t.bwPlusBb = t.bw + t.bb
+	t.Window = t.aw + t.bw
+
+	if t.lt > t.bw {
+		t.lt = t.bw
+	}
+}
+
+// timeArrival records the arrival of Max(), updates the windows, and
+// evicts by arrival time, which evicts the previous Max() if it's now
+// older than the behind window
+func (t *Tracker) timeArrival(seq uint16, tax *Taxonomy, now time.Time) {
+
+	tw := t.tw
+
+	switch {
+	case tax.Position == PositionInit || tax.Categroy == CategoryRestart:
+		tw.marks = append(tw.marks[:0], mark{seq: seq, at: now})
+		tw.head = 0
+		tw.evicted = false
+
+	case tax.Position == PositionAhead && tax.Categroy == CategoryWindow:
+		tw.marks = append(tw.marks, mark{seq: seq, at: now})
+		t.updateRate()
+	}
+
+	tax.Lost += t.evictByTime(now)
+	tax.Len = t.b.Len()
+}
+
+// updateRate measures the packet rate over the marks
+func (t *Tracker) updateRate() {
+
+	tw := t.tw
+
+	first := tw.marks[tw.head]
+	last := tw.marks[len(tw.marks)-1]
+
+	span := last.at.Sub(first.at)
+	if span < rateMinSpanCst {
+		return
+	}
+
+	tw.rate = float64(last.seq-first.seq) / span.Seconds()
+
+	t.setWindows()
+}
+
+// evictByTime evicts everything up to the newest Max() which arrived
+// before the behind window, and returns the number lost
+func (t *Tracker) evictByTime(now time.Time) (lost uint16) {
+
+	tw := t.tw
+	cutoff := now.Add(-tw.config.BW)
+
+	max, ok := t.b.Max()
+	if !ok {
+		return 0
+	}
+
+	var back uint16
+	found := false
+
+	for tw.head < len(tw.marks) {
+		m := tw.marks[tw.head]
+		// already evicted by the packet window
+		if isLess(m.seq, t.back) {
+			tw.head++
+			continue
+		}
+		if !m.at.Before(cutoff) {
+			break
+		}
+		found = true
+		if m.seq == max {
+			// Max() itself is never evicted, so its mark is kept, to evict
+			// it once the next Max() arrives
+			back = max
+			break
+		}
+		back = m.seq + 1
+		tw.head++
+	}
+
+	if tw.head >= marksCompactCst && tw.head > len(tw.marks)/2 {
+		n := copy(tw.marks, tw.marks[tw.head:])
+		tw.marks = tw.marks[:n]
+		tw.head = 0
+	}
+
+	if !found {
+		return 0
+	}
+
+	if !isLess(t.back, back) {
+		return 0
+	}
+
+	tw.evicted = true
+
+	return t.evictBefore(back)
+}
+
+// tooLate returns true if a behind packet is older than the back of the
+// time window
+func (t *Tracker) tooLate(seq uint16) bool {
+	return t.tw != nil && t.tw.evicted && isLess(seq, t.back)
+}
+
+// Rate returns the measured packets per second, or zero (0) for packet windows
+func (t *Tracker) Rate() float64 {
+	if t.tw == nil {
+		return 0
+	}
+	return t.tw.rate
+}
@@ -0,0 +1,134 @@
+package goTrackRTP
+
+// https://github.com/randomizedcoder/goTrackRTP/
+
+// See also: https://dave.cheney.net/2019/05/07/prefer-table-driven-tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeWindows(t *testing.T) {
+
+	type arrival struct {
+		seq uint16
+		at  time.Duration // since the start
+	}
+
+	type test struct {
+		name        string
+		tw          TimeWindows
+		arrivals    []arrival
+		Position    int
+		Categroy    int
+		Len         int
+		Lost        uint16 // of the last arrival
+		Window      uint16
+		marks       int // marks remaining after the last arrival
+		evictedBack uint16
+	}
+
+	ms := time.Millisecond
+	tw := TimeWindows{AW: time.Second, BW: time.Second, AB: time.Second, BB: time.Second}
+
+	tests := []test{
+		// the default rate is 50 packets per second, so the windows are 50 packets
+		{"default rate", tw,
+			[]arrival{{0, 0}, {1, 0}},
+			PositionAhead, CategoryWindow, 2, 0, 100, 2, 0},
+		// starting at 500, then measured at 1000 packets per second, so the windows are 100 packets
+		{"rate", TimeWindows{AW: 100 * ms, BW: 100 * ms, AB: 50 * ms, BB: 50 * ms, Rate: 500},
+			[]arrival{{0, 0}, {10, 10 * ms}, {20, 20 * ms}},
+			PositionAhead, CategoryWindow, 3, 0, 200, 3, 0},
+		// 0, 1, and 3 are older than the behind window, so 2 is lost
+		{"evicted lost", tw,
+			[]arrival{{0, 0}, {1, 0}, {3, 20 * ms}, {4, 2 * time.Second}},
+			PositionAhead, CategoryWindow, 1, 1, 8, 1, 4},
+		// the stream stopped, so before 3 is classified everything but Max()
+		// is evicted, 3 is counted as lost, and is then too late
+		{"pause", tw,
+			[]arrival{{0, 0}, {1, 0}, {2, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}, {8, 0}, {9, 0}, {10, 0}, {3, 10 * time.Second}},
+			PositionBehind, CategoryBuffer, 1, 1, 100, 1, 10},
+		// the previous Max() is evicted once the next Max() arrives, and the
+		// rate measured across the pause shrinks the windows to the minimum
+		{"pause resumes", tw,
+			[]arrival{{0, 0}, {1, 0}, {2, 0}, {3, 10 * time.Second}},
+			PositionAhead, CategoryWindow, 1, 0, 2 * (MinWindowCst + 1), 1, 3},
+		// 0 was evicted by time, so it's too late, rather than extending the window
+		{"too late", tw,
+			[]arrival{{0, 0}, {1, 0}, {2, 0}, {1, 10 * time.Second}, {0, 10 * time.Second}},
+			PositionBehind, CategoryBuffer, 1, 0, 100, 1, 2},
+		// the marks are reset, but the measured rate of 200 is kept
+		{"restart", tw,
+			[]arrival{{0, 0}, {1, 0}, {2, 10 * ms}, {30000, 20 * ms}},
+			PositionAhead, CategoryRestart, 1, 0, 400, 1, 30000},
+	}
+
+	start := time.Unix(1700000000, 0)
+
+	for i, tc := range tests {
+
+		tr, err := NewTime(tc.tw, debugLevelCst)
+		if err != nil {
+			t.Fatalf("%s, test:%d %s NewTime err:%v", t.Name(), i, tc.name, err)
+		}
+
+		var tax *Taxonomy
+		for _, a := range tc.arrivals {
+			var e error
+			tax, e = tr.PacketArrivalAt(a.seq, start.Add(a.at))
+			if e != nil {
+				t.Fatalf("%s, test:%d %s err != nil:%v", t.Name(), i, tc.name, e)
+			}
+		}
+
+		if tax.Position != tc.Position {
+			t.Fatalf("%s, test:%d %s tax.Position:%d != tc.Position:%d", t.Name(), i, tc.name, tax.Position, tc.Position)
+		}
+		if tax.Categroy != tc.Categroy {
+			t.Fatalf("%s, test:%d %s tax.Categroy:%d != tc.Categroy:%d", t.Name(), i, tc.name, tax.Categroy, tc.Categroy)
+		}
+		if tax.Len != tc.Len {
+			t.Fatalf("%s, test:%d %s tax.Len:%d != tc.Len:%d", t.Name(), i, tc.name, tax.Len, tc.Len)
+		}
+		if tax.Lost != tc.Lost {
+			t.Fatalf("%s, test:%d %s tax.Lost:%d != tc.Lost:%d", t.Name(), i, tc.name, tax.Lost, tc.Lost)
+		}
+		if tr.Window != tc.Window {
+			t.Fatalf("%s, test:%d %s tr.Window:%d != tc.Window:%d", t.Name(), i, tc.name, tr.Window, tc.Window)
+		}
+		if m := len(tr.tw.marks) - tr.tw.head; m != tc.marks {
+			t.Fatalf("%s, test:%d %s marks:%d != tc.marks:%d", t.Name(), i, tc.name, m, tc.marks)
+		}
+		if tr.back != tc.evictedBack {
+			t.Fatalf("%s, test:%d %s tr.back:%d != tc.evictedBack:%d", t.Name(), i, tc.name, tr.back, tc.evictedBack)
+		}
+	}
+}
+
+func TestTimeWindowsValidate(t *testing.T) {
+
+	type test struct {
+		name string
+		tw   TimeWindows
+		err  error
+	}
+
+	tests := []test{
+		{"ok", TimeWindows{AW: 1, BW: 1, AB: 1, BB: 1}, nil},
+		{"aw", TimeWindows{AW: 0, BW: 1, AB: 1, BB: 1}, ErrTimeWindowAW},
+		{"bw", TimeWindows{AW: 1, BW: -1, AB: 1, BB: 1}, ErrTimeWindowBW},
+		{"ab", TimeWindows{AW: 1, BW: 1, AB: 0, BB: 1}, ErrTimeWindowAB},
+		{"bb", TimeWindows{AW: 1, BW: 1, AB: 1, BB: 0}, ErrTimeWindowBB},
+		{"rate", TimeWindows{AW: 1, BW: 1, AB: 1, BB: 1, Rate: -1}, ErrTimeWindowRate},
+	}
+
+	for i, tc := range tests {
+		_, err := NewTime(tc.tw, 0)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s, test:%d %s err:%v != tc.err:%v", t.Name(), i, tc.name, err, tc.err)
+		}
+	}
+}